@@ -0,0 +1,355 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+// Unit-days and value-days are billed by 30-day months
+const billingDaysPerMonth = 30
+
+// Storage and handling rates of the customer owned materials
+type RateCard struct {
+	customerID        int
+	customerName      string
+	locationDayRate   decimal.Decimal // per occupied location a day
+	unitMonthRate     decimal.Decimal // per unit a month
+	valueMonthPercent decimal.Decimal // percent of the stored value a month
+	receiptFee        decimal.Decimal // per receipt
+	issueFee          decimal.Decimal // per issue for a job
+}
+
+// Customer owned stock at the end of a day
+type DailySnapshot struct {
+	day       time.Time
+	locations int
+	units     int
+	value     decimal.Decimal
+}
+
+type BillingLine struct {
+	description string
+	quantity    decimal.Decimal
+	unit        string
+	rate        string
+	amount      decimal.Decimal
+}
+
+type BillingStatement struct {
+	rateCard  RateCard
+	snapshots []DailySnapshot
+	receipts  int
+	issues    int
+	lines     []BillingLine
+}
+
+type StorageBillingReport struct {
+	Report
+	bilFilter SearchFilter
+}
+
+// Rate cards of all customers, zero rates when a customer has none
+func fetchRateCards(db *sql.DB) ([]RateCard, error) {
+	rows, err := db.Query(`
+		SELECT c.customer_id, c.name,
+			COALESCE(r.location_day_rate, 0), COALESCE(r.unit_month_rate, 0),
+			COALESCE(r.value_month_percent, 0), COALESCE(r.receipt_fee, 0), COALESCE(r.issue_fee, 0)
+		FROM customers c
+		LEFT JOIN rate_cards r ON r.customer_id = c.customer_id
+		ORDER BY c.name;`)
+	if err != nil {
+		log.Println("Error fetchRateCards1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rateCards []RateCard
+
+	for rows.Next() {
+		var r RateCard
+		if err := rows.Scan(&r.customerID, &r.customerName, &r.locationDayRate, &r.unitMonthRate,
+			&r.valueMonthPercent, &r.receiptFee, &r.issueFee); err != nil {
+			log.Println("Error fetchRateCards2: ", err)
+			return rateCards, err
+		}
+		rateCards = append(rateCards, r)
+	}
+
+	return rateCards, rows.Err()
+}
+
+func fetchRateCard(db *sql.DB, customerID int) (RateCard, error) {
+	rateCards, err := fetchRateCards(db)
+	if err != nil {
+		return RateCard{}, err
+	}
+
+	for _, r := range rateCards {
+		if r.customerID == customerID {
+			return r, nil
+		}
+	}
+
+	return RateCard{}, errors.New("unknown customer")
+}
+
+func saveRateCard(db *sql.DB, r RateCard) error {
+	if r.locationDayRate.IsNegative() || r.unitMonthRate.IsNegative() || r.valueMonthPercent.IsNegative() ||
+		r.receiptFee.IsNegative() || r.issueFee.IsNegative() {
+		return errors.New("The rates cannot be negative")
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO rate_cards (customer_id, location_day_rate, unit_month_rate,
+			value_month_percent, receipt_fee, issue_fee)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (customer_id) DO UPDATE SET
+			location_day_rate = EXCLUDED.location_day_rate,
+			unit_month_rate = EXCLUDED.unit_month_rate,
+			value_month_percent = EXCLUDED.value_month_percent,
+			receipt_fee = EXCLUDED.receipt_fee,
+			issue_fee = EXCLUDED.issue_fee,
+			updated_at = NOW();`,
+		r.customerID, r.locationDayRate, r.unitMonthRate, r.valueMonthPercent, r.receiptFee, r.issueFee)
+	if err != nil {
+		log.Println("Error saveRateCard: ", err)
+	}
+
+	return err
+}
+
+// Customer owned stock of every day of the period, the balances and
+// the FIFO values roll forward from the nearest inventory snapshot
+func fetchDailySnapshots(db *sql.DB, customerID int, from time.Time, to time.Time) ([]DailySnapshot, error) {
+	rows, err := db.Query(`
+		WITH days AS (
+			SELECT generate_series($2::date, $3::date, interval '1 day')::date AS day
+		)
+		SELECT d.day, COUNT(DISTINCT b.location_id), COALESCE(SUM(b.quantity), 0), COALESCE(SUM(b.value), 0)
+		FROM days d
+		LEFT JOIN LATERAL (
+			SELECT mb.location_id, mb.quantity, mb.value
+			FROM material_balances(d.day + 1) mb
+			WHERE mb.customer_id = $1 AND mb.owner = 'Customer' AND mb.quantity > 0
+		) b ON TRUE
+		GROUP BY d.day
+		ORDER BY d.day;`,
+		customerID, from, to)
+	if err != nil {
+		log.Println("Error fetchDailySnapshots1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []DailySnapshot
+
+	for rows.Next() {
+		var s DailySnapshot
+		if err := rows.Scan(&s.day, &s.locations, &s.units, &s.value); err != nil {
+			log.Println("Error fetchDailySnapshots2: ", err)
+			return snapshots, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// Storage and handling charges of a customer for the period
+func computeBilling(db *sql.DB, customerID int, from time.Time, to time.Time) (BillingStatement, error) {
+	var b BillingStatement
+
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return b, errors.New("The billing period is not valid")
+	}
+
+	var err error
+	if b.rateCard, err = fetchRateCard(db, customerID); err != nil {
+		return b, err
+	}
+	if b.snapshots, err = fetchDailySnapshots(db, customerID, from, to); err != nil {
+		return b, err
+	}
+
+	// Every receipt entry is a receipt, an issue may use several cost layers
+	err = db.QueryRow(`
+		SELECT
+			COUNT(*) FILTER (WHERE tl.transaction_type = $4),
+			COUNT(DISTINCT (tl.material_id, tl.updated_at)) FILTER (WHERE tl.transaction_type = $5)
+		FROM transactions_log tl
+		WHERE tl.customer_id = $1 AND tl.owner = 'Customer' AND
+			tl.updated_at >= $2 AND tl.updated_at < $3;`,
+		customerID, from, getDayAfter(to), receiptTrx, usageTrx).Scan(&b.receipts, &b.issues)
+	if err != nil {
+		log.Println("Error computeBilling: ", err)
+		return b, err
+	}
+
+	var locationDays, unitDays int64
+	valueDays := decimal.Zero
+	for _, s := range b.snapshots {
+		locationDays += int64(s.locations)
+		unitDays += int64(s.units)
+		valueDays = valueDays.Add(s.value)
+	}
+
+	r := b.rateCard
+	daysPerMonth := decimal.New(billingDaysPerMonth, 0)
+	b.addLine("Storage, occupied locations", decimal.New(locationDays, 0), "location-days",
+		r.locationDayRate, formatUnitCost(r.locationDayRate))
+	b.addLine("Storage, units", decimal.New(unitDays, 0).Div(daysPerMonth), "unit-months",
+		r.unitMonthRate, formatUnitCost(r.unitMonthRate))
+	b.addLine("Storage, value", valueDays.Div(daysPerMonth), "USD-months",
+		r.valueMonthPercent.Shift(-2), formatAmount(r.valueMonthPercent)+"%")
+	b.addLine("Handling, receipts", decimal.New(int64(b.receipts), 0), "receipts",
+		r.receiptFee, formatUnitCost(r.receiptFee))
+	b.addLine("Handling, issues", decimal.New(int64(b.issues), 0), "issues",
+		r.issueFee, formatUnitCost(r.issueFee))
+
+	// Stock sold to the customer is charged, stock bought by Tag is credited
+	transfers, err := fetchOwnershipTransfers(db, SearchFilter{customerID: customerID, dateFrom: from, dateTo: to}, 0)
+	if err != nil {
+		return b, err
+	}
+	for _, t := range transfers {
+		b.lines = append(b.lines, BillingLine{
+			description: "Ownership transfer No. " + strconv.Itoa(t.id) + ", " + t.stockID + " to " + t.toOwner,
+			quantity:    decimal.New(int64(t.quantity), 0),
+			unit:        "units",
+			rate:        formatUnitCost(t.transferPrice),
+			amount:      t.getAmountDue(),
+		})
+	}
+
+	return b, nil
+}
+
+// A charge line, the rates that are not set are not billed
+func (b *BillingStatement) addLine(description string, quantity decimal.Decimal, unit string, rate decimal.Decimal, rateStr string) {
+	if rate.IsZero() {
+		return
+	}
+
+	b.lines = append(b.lines, BillingLine{
+		description: description,
+		quantity:    quantity,
+		unit:        unit,
+		rate:        rateStr,
+		amount:      quantity.Mul(rate),
+	})
+}
+
+func formatAmount(value decimal.Decimal) string {
+	return value.StringFixed(2)
+}
+
+// The errors are shown by the filter dialog, the reloads and the scheduled
+// reports only log them
+func (r StorageBillingReport) getReportList() [][]string {
+	b, err := computeBilling(r.db, r.bilFilter.customerID, r.bilFilter.dateFrom, r.bilFilter.dateTo)
+	if err != nil {
+		log.Println("Error StorageBillingReport: ", err)
+		return getBillingList(BillingStatement{})
+	}
+
+	return getBillingList(b)
+}
+
+func getBillingList(b BillingStatement) [][]string {
+	bilList := [][]string{
+		{"Charge", "Quantity", "Unit", "Rate", "Amount, USD"},
+	}
+
+	for _, line := range b.lines {
+		bilList = append(bilList, []string{
+			line.description,
+			formatAmount(line.quantity),
+			line.unit,
+			line.rate,
+			formatMoney(line.amount),
+		})
+	}
+
+	return bilList
+}
+
+func (r StorageBillingReport) getColumnTypes() []ColumnType {
+	return []ColumnType{TextColumn, NumberColumn, TextColumn, TextColumn, ValueColumn}
+}
+
+func (r StorageBillingReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Storage Billing Statement",
+		customerName: r.bilFilter.customerName,
+		period:       formatPeriod(r.bilFilter.dateFrom, r.bilFilter.dateTo),
+	}
+}
+
+func (r StorageBillingReport) showReport() {
+	customers, _ := fetchCustomers(r.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	dateFromEntry := newDateEntry(r.window)
+	dateToEntry := newDateEntry(r.window)
+	rangeSelector := newQuickRangeSelector(dateFromEntry, dateToEntry)
+	// The previous month by default
+	rangeSelector.SetSelected("Last month")
+
+	dialog := dialog.NewForm("Billing Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Period", rangeSelector),
+			widget.NewFormItem("Date From", dateFromEntry),
+			widget.NewFormItem("Date To", dateToEntry),
+		}, func(confirm bool) {
+			if confirm {
+				from, to, err := getDateRange(dateFromEntry, dateToEntry)
+				if err == nil && (from.IsZero() || to.IsZero()) {
+					err = errors.New("Choose both Date From and Date To")
+				}
+				if err == nil && customerSelector.Selected == "" {
+					err = errors.New("Choose a customer")
+				}
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), r.window)
+					return
+				}
+
+				r.bilFilter = SearchFilter{
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+					dateFrom:     from,
+					dateTo:       to,
+				}
+
+				b, err := computeBilling(r.db, r.bilFilter.customerID, from, to)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), r.window)
+					return
+				}
+
+				bilList := getBillingList(b)
+				showReportWindow(r.app, "Storage Billing: "+customerSelector.Selected, r, bilList,
+					"billing_"+safeFileName(customerSelector.Selected)+"_"+from.Format("2006-01"),
+					fyne.NewSize(1000, 500))
+			}
+		}, r.window)
+
+	dialog.Resize(fyne.NewSize(600, 300))
+	dialog.Show()
+}
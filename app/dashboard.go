@@ -0,0 +1,334 @@
+package main
+
+import (
+	"database/sql"
+	"image/color"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	dashboardRefreshInterval = time.Minute
+	dashboardTrendDays       = 30
+	dashboardTopCustomers    = 5
+)
+
+// Total value of an owner or a customer
+type NamedValue struct {
+	name  string
+	value decimal.Decimal
+}
+
+type DashboardData struct {
+	valueByOwner     []NamedValue
+	belowMinimum     int
+	pendingShipments int
+	oldestShipment   sql.NullTime
+	todayReceipts    int
+	todayUsage       int
+	topCustomers     []NamedValue
+	dailyUsage       []float64 // the oldest day first
+}
+
+// Reports and screens opened by tapping the tiles
+type DashboardActions struct {
+	showValuation    func()
+	showInventory    func()
+	showIncoming     func()
+	showTransactions func()
+	showForecast     func()
+}
+
+func fetchDashboardData(db *sql.DB) (DashboardData, error) {
+	data := DashboardData{dailyUsage: make([]float64, dashboardTrendDays)}
+
+	rows, err := db.Query(`
+		SELECT tl.owner, SUM(tl.quantity_change * tl.cost)
+		FROM transactions_log tl
+		WHERE tl.owner IS NOT NULL
+		GROUP BY tl.owner
+		ORDER BY tl.owner;`)
+	if err != nil {
+		log.Println("Error fetchDashboardData1: ", err)
+		return data, err
+	}
+	for rows.Next() {
+		var ownerValue NamedValue
+		if err := rows.Scan(&ownerValue.name, &ownerValue.value); err != nil {
+			rows.Close()
+			log.Println("Error fetchDashboardData2: ", err)
+			return data, err
+		}
+		data.valueByOwner = append(data.valueByOwner, ownerValue)
+	}
+	rows.Close()
+
+	// Stock IDs of a customer with the quantity of all locations under the minimum
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT i.item_id
+			FROM items i
+			LEFT JOIN materials m ON m.item_id = i.item_id
+			WHERE i.is_active
+			GROUP BY i.item_id
+			HAVING COALESCE(SUM(m.quantity), 0) < COALESCE(MAX(i.min_required_quantity), 0)
+		) below_minimum;`).Scan(&data.belowMinimum)
+	if err != nil {
+		log.Println("Error fetchDashboardData3: ", err)
+		return data, err
+	}
+
+	err = db.QueryRow(`SELECT COUNT(*), MIN(created_at) FROM incoming_materials;`).
+		Scan(&data.pendingShipments, &data.oldestShipment)
+	if err != nil {
+		log.Println("Error fetchDashboardData4: ", err)
+		return data, err
+	}
+
+	err = db.QueryRow(`
+		SELECT COALESCE(SUM(quantity_change) FILTER (WHERE transaction_type = 'Receipt'), 0),
+			   COALESCE(-SUM(quantity_change) FILTER (WHERE transaction_type = 'Usage'), 0)
+		FROM transactions_log
+		WHERE updated_at >= CURRENT_DATE;`).Scan(&data.todayReceipts, &data.todayUsage)
+	if err != nil {
+		log.Println("Error fetchDashboardData5: ", err)
+		return data, err
+	}
+
+	rows, err = db.Query(`
+		SELECT c.name, SUM(tl.quantity_change * tl.cost) AS total_value
+		FROM transactions_log tl
+		JOIN customers c ON c.customer_id = tl.customer_id
+		GROUP BY c.name
+		HAVING SUM(tl.quantity_change) > 0
+		ORDER BY total_value DESC
+		LIMIT $1;`, dashboardTopCustomers)
+	if err != nil {
+		log.Println("Error fetchDashboardData6: ", err)
+		return data, err
+	}
+	for rows.Next() {
+		var customerValue NamedValue
+		if err := rows.Scan(&customerValue.name, &customerValue.value); err != nil {
+			rows.Close()
+			log.Println("Error fetchDashboardData7: ", err)
+			return data, err
+		}
+		data.topCustomers = append(data.topCustomers, customerValue)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT CURRENT_DATE - updated_at::date AS days_ago, -SUM(quantity_change)
+		FROM transactions_log
+		WHERE transaction_type = 'Usage' AND
+			  updated_at >= CURRENT_DATE - ($1::int - 1)
+		GROUP BY 1;`, dashboardTrendDays)
+	if err != nil {
+		log.Println("Error fetchDashboardData8: ", err)
+		return data, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var daysAgo int
+		var usage float64
+		if err := rows.Scan(&daysAgo, &usage); err != nil {
+			log.Println("Error fetchDashboardData9: ", err)
+			return data, err
+		}
+		if daysAgo >= 0 && daysAgo < dashboardTrendDays {
+			data.dailyUsage[dashboardTrendDays-1-daysAgo] = usage
+		}
+	}
+
+	return data, rows.Err()
+}
+
+// Home screen tiles with the key figures, refreshed on an interval
+type Dashboard struct {
+	db *sql.DB
+
+	valueLabel     *widget.Label
+	belowMinLabel  *widget.Label
+	incomingLabel  *widget.Label
+	todayLabel     *widget.Label
+	customersLabel *widget.Label
+	updatedLabel   *widget.Label
+	trendChart     *fyne.Container
+	trendLayout    *barChartLayout
+
+	// The tiles are refreshed on a single goroutine, see startAutoRefresh
+	refreshes chan struct{}
+}
+
+func newDashboard(db *sql.DB, actions DashboardActions) (*Dashboard, fyne.CanvasObject) {
+	d := &Dashboard{
+		db:             db,
+		valueLabel:     widget.NewLabel(""),
+		belowMinLabel:  widget.NewLabel(""),
+		incomingLabel:  widget.NewLabel(""),
+		todayLabel:     widget.NewLabel(""),
+		customersLabel: widget.NewLabel(""),
+		updatedLabel:   widget.NewLabel(""),
+		trendLayout:    &barChartLayout{},
+		refreshes:      make(chan struct{}, 1),
+	}
+
+	// A bar of every day, the layout scales them to the usage
+	var bars []fyne.CanvasObject
+	for i := 0; i < dashboardTrendDays; i++ {
+		bars = append(bars, canvas.NewRectangle(color.NRGBA{R: 0x29, G: 0x6f, B: 0xf6, A: 0xff}))
+	}
+	d.trendChart = container.New(d.trendLayout, bars...)
+
+	tiles := container.New(layout.NewGridLayoutWithColumns(3),
+		newDashboardTile("Inventory Value by Owner", d.valueLabel, actions.showValuation),
+		newDashboardTile("SKUs Below Minimum", d.belowMinLabel, actions.showInventory),
+		newDashboardTile("Pending Incoming Shipments", d.incomingLabel, actions.showIncoming),
+		newDashboardTile("Today's Receipts and Usage", d.todayLabel, actions.showTransactions),
+		newDashboardTile("Top Customers by Value", d.customersLabel, actions.showValuation),
+		newDashboardTile("Usage, Last "+strconv.Itoa(dashboardTrendDays)+" Days", d.trendChart, actions.showForecast),
+	)
+
+	header := container.NewBorder(nil, nil, nil,
+		container.NewHBox(d.updatedLabel, widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), d.requestRefresh)),
+	)
+
+	d.refresh()
+
+	return d, container.NewBorder(header, nil, nil, nil, tiles)
+}
+
+// A card that opens a report when it is tapped
+func newDashboardTile(title string, content fyne.CanvasObject, onTapped func()) fyne.CanvasObject {
+	button := widget.NewButton("", onTapped)
+	card := widget.NewCard("", title, content)
+
+	return container.NewStack(button, card)
+}
+
+func (d *Dashboard) refresh() {
+	data, err := fetchDashboardData(d.db)
+	if err != nil {
+		d.updatedLabel.SetText("Refresh error: " + err.Error())
+		return
+	}
+
+	d.show(data)
+}
+
+// Put the data on the tiles, the only place the widgets are updated
+func (d *Dashboard) show(data DashboardData) {
+	var values []string
+	for _, ownerValue := range data.valueByOwner {
+		values = append(values, ownerValue.name+": "+formatMoney(ownerValue.value))
+	}
+	d.valueLabel.SetText(strings.Join(values, "\n"))
+
+	d.belowMinLabel.SetText(strconv.Itoa(data.belowMinimum))
+
+	incoming := strconv.Itoa(data.pendingShipments) + " shipment(s)"
+	if data.oldestShipment.Valid {
+		days := int(time.Since(data.oldestShipment.Time).Hours() / 24)
+		incoming += "\nOldest: " + strconv.Itoa(days) + " day(s)"
+	}
+	d.incomingLabel.SetText(incoming)
+
+	d.todayLabel.SetText("Received: " + strconv.Itoa(data.todayReceipts) +
+		"\nUsed: " + strconv.Itoa(data.todayUsage))
+
+	var customers []string
+	for _, customerValue := range data.topCustomers {
+		customers = append(customers, customerValue.name+": "+formatMoney(customerValue.value))
+	}
+	d.customersLabel.SetText(strings.Join(customers, "\n"))
+
+	d.trendLayout.setValues(data.dailyUsage)
+	d.trendChart.Refresh()
+
+	d.updatedLabel.SetText("Updated at " + time.Now().Format("15:04"))
+}
+
+// Ask for a refresh, the requests made during a refresh are merged into one
+func (d *Dashboard) requestRefresh() {
+	select {
+	case d.refreshes <- struct{}{}:
+	default:
+	}
+}
+
+// Refresh the tiles on changes, on request and on an interval until the app
+// is closed. The refreshes run one after another on a single goroutine.
+func (d *Dashboard) startAutoRefresh() {
+	changes.subscribe(d.requestRefresh, materialsTable, incomingMaterialsTable, transactionsTable)
+
+	go func() {
+		ticker := time.NewTicker(dashboardRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-d.refreshes:
+			}
+			d.refresh()
+		}
+	}()
+}
+
+// Bars of the values from left to right, scaled to the highest one.
+// The values are set by the refreshes while the canvas lays the bars out.
+type barChartLayout struct {
+	mu     sync.Mutex
+	values []float64
+}
+
+func (b *barChartLayout) setValues(values []float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.values = values
+}
+
+func (b *barChartLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var maxValue float64
+	for _, value := range b.values {
+		if value > maxValue {
+			maxValue = value
+		}
+	}
+
+	barWidth := size.Width / float32(len(objects))
+	for i, o := range objects {
+		var height float32
+		if maxValue > 0 && i < len(b.values) {
+			height = size.Height * float32(b.values[i]/maxValue)
+		}
+		o.Resize(fyne.NewSize(barWidth*0.8, height))
+		o.Move(fyne.NewPos(barWidth*float32(i), size.Height-height))
+	}
+}
+
+func (b *barChartLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(200, 80)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Date input format of the system locale
+var dateLayout = getLocaleDateLayout()
+
+var quickRanges = []string{
+	"Today", "This month", "Last month", "This quarter", "Last quarter", "Year to date",
+}
+
+// Month first in the US and the countries using the same order,
+// day first elsewhere
+func getLocaleDateLayout() string {
+	locale := lang.SystemLocale().String()
+
+	parts := strings.Split(locale, "-")
+	region := parts[len(parts)-1]
+	if len(parts) == 1 {
+		region = ""
+	}
+
+	switch region {
+	case "", "US", "CA", "PH", "FM", "MH", "PW":
+		if len(parts) == 1 && locale != "en" {
+			return "02/01/2006"
+		}
+		return "01/02/2006"
+	default:
+		return "02/01/2006"
+	}
+}
+
+func getDateHint() string {
+	if dateLayout == "01/02/2006" {
+		return "MM/DD/YYYY"
+	}
+
+	return "DD/MM/YYYY"
+}
+
+// Entry of a date with a calendar picker
+type DateEntry struct {
+	widget.Entry
+	window fyne.Window
+}
+
+func newDateEntry(window fyne.Window) *DateEntry {
+	e := &DateEntry{window: window}
+	e.ExtendBaseWidget(e)
+
+	e.SetPlaceHolder(getDateHint())
+	e.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		if _, err := parseDate(text); err != nil {
+			return errors.New("Use the " + getDateHint() + " format")
+		}
+		return nil
+	}
+	e.ActionItem = widget.NewButtonWithIcon("", theme.HistoryIcon(), e.showCalendar)
+
+	return e
+}
+
+func (e *DateEntry) SetDate(date time.Time) {
+	e.SetText(date.Format(dateLayout))
+}
+
+// The chosen date, zero time when the entry is empty
+func (e *DateEntry) GetDate() (time.Time, error) {
+	if strings.TrimSpace(e.Text) == "" {
+		return time.Time{}, nil
+	}
+
+	return parseDate(e.Text)
+}
+
+func (e *DateEntry) showCalendar() {
+	selected, err := e.GetDate()
+	if err != nil || selected.IsZero() {
+		selected = time.Now()
+	}
+	month := time.Date(selected.Year(), selected.Month(), 1, 0, 0, 0, 0, time.Local)
+
+	var calendarDialog dialog.Dialog
+	monthLabel := widget.NewLabel("")
+	monthLabel.Alignment = fyne.TextAlignCenter
+	monthLabel.TextStyle.Bold = true
+	daysGrid := container.NewGridWithColumns(7)
+
+	var render func()
+	render = func() {
+		monthLabel.SetText(month.Format("January 2006"))
+		daysGrid.Objects = nil
+
+		for _, weekday := range []string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"} {
+			weekdayLabel := widget.NewLabel(weekday)
+			weekdayLabel.Alignment = fyne.TextAlignCenter
+			daysGrid.Add(weekdayLabel)
+		}
+		for i := 0; i < int(month.Weekday()); i++ {
+			daysGrid.Add(widget.NewLabel(""))
+		}
+		for day := month; day.Month() == month.Month(); day = day.AddDate(0, 0, 1) {
+			date := day
+			dayButton := widget.NewButton(strconv.Itoa(date.Day()), func() {
+				e.SetDate(date)
+				calendarDialog.Hide()
+			})
+			if isSameDay(date, selected) {
+				dayButton.Importance = widget.HighImportance
+			}
+			daysGrid.Add(dayButton)
+		}
+
+		daysGrid.Refresh()
+	}
+
+	prevButton := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		month = month.AddDate(0, -1, 0)
+		render()
+	})
+	nextButton := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		month = month.AddDate(0, 1, 0)
+		render()
+	})
+	todayButton := widget.NewButton("Today", func() {
+		e.SetDate(time.Now())
+		calendarDialog.Hide()
+	})
+
+	render()
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, prevButton, nextButton, monthLabel),
+		todayButton, nil, nil,
+		daysGrid,
+	)
+
+	calendarDialog = dialog.NewCustom("Choose a date", "Cancel", content, e.window)
+	calendarDialog.Show()
+}
+
+func isSameDay(a time.Time, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// Selector of the common date ranges filling the From and To entries
+func newQuickRangeSelector(dateFrom *DateEntry, dateTo *DateEntry) *widget.Select {
+	return widget.NewSelect(quickRanges, func(name string) {
+		from, to := getQuickRange(name, time.Now())
+		dateFrom.SetDate(from)
+		dateTo.SetDate(to)
+	})
+}
+
+func getQuickRange(name string, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	quarterStart := time.Date(now.Year(), time.Month((int(now.Month())-1)/3*3+1), 1, 0, 0, 0, 0, time.Local)
+
+	switch name {
+	case "This month":
+		return monthStart, today
+	case "Last month":
+		return monthStart.AddDate(0, -1, 0), monthStart.AddDate(0, 0, -1)
+	case "This quarter":
+		return quarterStart, today
+	case "Last quarter":
+		return quarterStart.AddDate(0, -3, 0), quarterStart.AddDate(0, 0, -1)
+	case "Year to date":
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local), today
+	default:
+		return today, today
+	}
+}
+
+// The exclusive upper bound of a date for the queries, a zero date stays zero
+func getDayAfter(date time.Time) time.Time {
+	if date.IsZero() {
+		return date
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, time.Local)
+}
+
+// A query parameter of an optional date
+func toNullTime(date time.Time) sql.NullTime {
+	return sql.NullTime{Time: date, Valid: !date.IsZero()}
+}
+
+func formatReportDate(date time.Time) string {
+	return date.Format("01/02/2006")
+}
+
+// Read the From and To entries, either of them may be empty
+func getDateRange(dateFrom *DateEntry, dateTo *DateEntry) (time.Time, time.Time, error) {
+	from, err := dateFrom.GetDate()
+	if err != nil {
+		return from, time.Time{}, errors.New("Date From must be in the " + getDateHint() + " format")
+	}
+	to, err := dateTo.GetDate()
+	if err != nil {
+		return from, to, errors.New("Date To must be in the " + getDateHint() + " format")
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return from, to, errors.New("Date From must not be after Date To")
+	}
+
+	return from, to, nil
+}
+
+// The period of a report header, open ends are left out
+func formatPeriod(from time.Time, to time.Time) string {
+	switch {
+	case from.IsZero() && to.IsZero():
+		return "All dates"
+	case from.IsZero():
+		return "Until " + formatReportDate(to)
+	case to.IsZero():
+		return "Since " + formatReportDate(from)
+	default:
+		return formatReportDate(from) + " - " + formatReportDate(to)
+	}
+}
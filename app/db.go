@@ -15,11 +15,13 @@ const (
 	dbname   = "tag_db"
 )
 
-func connectToDB() (*sql.DB, error) {
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+func getConnectionInfo() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname)
+}
 
-	db, err := sql.Open("postgres", psqlInfo)
+func connectToDB() (*sql.DB, error) {
+	db, err := sql.Open("postgres", getConnectionInfo())
 	if err != nil {
 		log.Println(err)
 		return nil, errors.New(err.Error())
@@ -32,3 +34,33 @@ func connectToDB() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// Queries of both the connection pool and a DB transaction
+type queryExecutor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Run fn in a DB transaction, it is committed when fn succeeds and rolled back otherwise
+func withTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Println("Error withTransaction1: ", err)
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			log.Println("Error withTransaction2: ", errRollback)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Error withTransaction3: ", err)
+		return err
+	}
+
+	return nil
+}
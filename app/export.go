@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	csvFormat  = "csv"
+	xlsxFormat = "xlsx"
+	pdfFormat  = "pdf"
+)
+
+const xlsxSheetName = "Report"
+
+// Build the File menu with the export options for a report window.
+// The list is read when a file is saved, so the menu stays valid after a reload.
+func getReportMenu(window fyne.Window, r Reporter, getList func() [][]string, fileName string) *fyne.MainMenu {
+	fileMenu := fyne.NewMenu("File",
+		fyne.NewMenuItem("Save as .csv", func() {
+			saveReport(window, r, getList(), fileName, csvFormat)
+		}),
+		fyne.NewMenuItem("Save as .xlsx", func() {
+			saveReport(window, r, getList(), fileName, xlsxFormat)
+		}),
+		fyne.NewMenuItem("Save as .pdf", func() {
+			saveReport(window, r, getList(), fileName, pdfFormat)
+		}),
+	)
+
+	return fyne.NewMainMenu(fileMenu)
+}
+
+// Ask the user for a location and write the report in the chosen format
+func saveReport(window fyne.Window, r Reporter, list [][]string, fileName string, format string) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			log.Println("Error saveReport1: ", err)
+			dialog.ShowError(err, window)
+			return
+		}
+		// The dialog has been cancelled
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := writeReport(writer, r, list, format); err != nil {
+			log.Println("Error saveReport2: ", err)
+			dialog.ShowError(err, window)
+			return
+		}
+
+		dialog.ShowInformation("Success", "File has been saved to "+writer.URI().Path(), window)
+	}, window)
+
+	saveDialog.SetFileName(fileName + "." + format)
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{"." + format}))
+	saveDialog.Resize(fyne.NewSize(800, 600))
+	saveDialog.Show()
+}
+
+func writeReport(w io.Writer, r Reporter, list [][]string, format string) error {
+	switch format {
+	case csvFormat:
+		return writeCSV(w, list)
+	case xlsxFormat:
+		return writeXLSX(w, list, r.getColumnTypes())
+	case pdfFormat:
+		return writePDF(w, list, r.getColumnTypes(), r.getReportHeader())
+	default:
+		return errors.New("unsupported report format: " + format)
+	}
+}
+
+func writeCSV(w io.Writer, list [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(list); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+// Write the report as a workbook with typed cells, a styled and frozen
+// header row, an autofilter and a totals row for quantity and value columns
+func writeXLSX(w io.Writer, list [][]string, columnTypes []ColumnType) error {
+	if len(list) == 0 {
+		return errors.New("the report is empty")
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", xlsxSheetName); err != nil {
+		return err
+	}
+
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+
+	numCols := len(list[0])
+	colWidths := make([]int, numCols)
+
+	for r, row := range list {
+		for c, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(c+1, r+1)
+
+			if len(value) > colWidths[c] {
+				colWidths[c] = len(value)
+			}
+
+			if r == 0 {
+				f.SetCellStr(xlsxSheetName, cell, value)
+				f.SetCellStyle(xlsxSheetName, cell, cell, styles.header)
+				continue
+			}
+
+			if err := setXLSXCell(f, cell, value, getColumnType(columnTypes, c), styles); err != nil {
+				return err
+			}
+		}
+	}
+
+	lastRow := len(list)
+	lastCell, _ := excelize.CoordinatesToCellName(numCols, lastRow)
+
+	// Totals row
+	if lastRow > 1 {
+		totalsRow := lastRow + 1
+		firstCell, _ := excelize.CoordinatesToCellName(1, totalsRow)
+		totalsLastCell, _ := excelize.CoordinatesToCellName(numCols, totalsRow)
+		f.SetCellStyle(xlsxSheetName, firstCell, totalsLastCell, styles.total)
+		f.SetCellStr(xlsxSheetName, firstCell, "Total")
+
+		for c := 0; c < numCols; c++ {
+			colType := getColumnType(columnTypes, c)
+			if colType != QuantityColumn && colType != ValueColumn {
+				continue
+			}
+
+			colName, _ := excelize.ColumnNumberToName(c + 1)
+			cell := colName + strconv.Itoa(totalsRow)
+			f.SetCellFormula(xlsxSheetName, cell,
+				"SUM("+colName+"2:"+colName+strconv.Itoa(lastRow)+")")
+
+			if colType == ValueColumn {
+				f.SetCellStyle(xlsxSheetName, cell, cell, styles.totalMoney)
+			} else {
+				f.SetCellStyle(xlsxSheetName, cell, cell, styles.totalNumber)
+			}
+		}
+	}
+
+	for c, width := range colWidths {
+		colName, _ := excelize.ColumnNumberToName(c + 1)
+		f.SetColWidth(xlsxSheetName, colName, colName, float64(min(max(width, 8), 50)+2))
+	}
+
+	if err := f.SetPanes(xlsxSheetName, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+
+	if err := f.AutoFilter(xlsxSheetName, "A1:"+lastCell, nil); err != nil {
+		return err
+	}
+
+	return f.Write(w)
+}
+
+type xlsxStyles struct {
+	header      int
+	number      int
+	money       int
+	price       int // unit costs with the unit cost precision
+	date        int
+	total       int
+	totalNumber int
+	totalMoney  int
+}
+
+func newXLSXStyles(f *excelize.File) (xlsxStyles, error) {
+	var styles xlsxStyles
+	var err error
+
+	moneyFmt := `"$"#,##0.00;[Red]-"$"#,##0.00`
+	priceDecimals := strings.Repeat("0", int(unitCostPrecision))
+	priceFmt := `"$"#,##0.` + priceDecimals + `;[Red]-"$"#,##0.` + priceDecimals
+	dateFmt := "mm/dd/yyyy"
+	totalBorder := []excelize.Border{{Type: "top", Color: "000000", Style: 1}}
+
+	if styles.header, err = f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"4F81BD"}},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	}); err != nil {
+		return styles, err
+	}
+	if styles.number, err = f.NewStyle(&excelize.Style{NumFmt: 3}); err != nil {
+		return styles, err
+	}
+	if styles.money, err = f.NewStyle(&excelize.Style{CustomNumFmt: &moneyFmt}); err != nil {
+		return styles, err
+	}
+	if styles.price, err = f.NewStyle(&excelize.Style{CustomNumFmt: &priceFmt}); err != nil {
+		return styles, err
+	}
+	if styles.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt}); err != nil {
+		return styles, err
+	}
+	if styles.total, err = f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Border: totalBorder,
+	}); err != nil {
+		return styles, err
+	}
+	if styles.totalNumber, err = f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Border: totalBorder,
+		NumFmt: 3,
+	}); err != nil {
+		return styles, err
+	}
+	if styles.totalMoney, err = f.NewStyle(&excelize.Style{
+		Font:         &excelize.Font{Bold: true},
+		Border:       totalBorder,
+		CustomNumFmt: &moneyFmt,
+	}); err != nil {
+		return styles, err
+	}
+
+	return styles, nil
+}
+
+// Write a cell value using the type of its column.
+// Values that cannot be parsed are kept as text.
+func setXLSXCell(f *excelize.File, cell string, value string, colType ColumnType, styles xlsxStyles) error {
+	switch colType {
+	case NumberColumn, QuantityColumn:
+		if number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64); err == nil {
+			f.SetCellStyle(xlsxSheetName, cell, cell, styles.number)
+			return f.SetCellFloat(xlsxSheetName, cell, number, -1, 64)
+		}
+	case PriceColumn, AmountColumn, ValueColumn:
+		if money, err := parseMoney(value); err == nil {
+			style := styles.money
+			if colType == PriceColumn {
+				style = styles.price
+			}
+			f.SetCellStyle(xlsxSheetName, cell, cell, style)
+			number, _ := money.Float64()
+			return f.SetCellFloat(xlsxSheetName, cell, number, -1, 64)
+		}
+	case DateColumn:
+		if date, err := time.Parse("1/2/2006", value); err == nil {
+			f.SetCellStyle(xlsxSheetName, cell, cell, styles.date)
+			return f.SetCellValue(xlsxSheetName, cell, date)
+		}
+	}
+
+	return f.SetCellStr(xlsxSheetName, cell, value)
+}
+
+func getColumnType(columnTypes []ColumnType, col int) ColumnType {
+	if col < len(columnTypes) {
+		return columnTypes[col]
+	}
+
+	return TextColumn
+}
+
+// Parse a value formatted by accLib back to an exact number
+func parseMoney(value string) (decimal.Decimal, error) {
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+	return decimal.NewFromString(value)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	forecastWeeks = 12  // completed weeks of the usage history
+	forecastAlpha = 0.3 // smoothing factor of the weekly usage
+)
+
+type UsageForecast struct {
+	customerName   string
+	stockID        string
+	onHand         int
+	weeklyUsage    []float64 // the oldest week first
+	avgWeekly      float64
+	smoothedWeekly float64
+}
+
+type ForecastReport struct {
+	Report
+	fcsFilter SearchFilter
+}
+
+// Usage forecasts by customer and stock ID
+func fetchUsageForecasts(db *sql.DB, customerID int) (map[string]*UsageForecast, []*UsageForecast, error) {
+	forecastsMap := make(map[string]*UsageForecast)
+	var forecasts []*UsageForecast
+
+	rows, err := db.Query(`
+		SELECT COALESCE(c.name, ''), m.stock_id, SUM(m.quantity)
+		FROM materials m
+		LEFT JOIN customers c ON c.customer_id = m.customer_id
+		WHERE ($1 = 0 OR m.customer_id = $1)
+		GROUP BY c.name, m.stock_id
+		ORDER BY c.name, m.stock_id;`, customerID)
+	if err != nil {
+		log.Println("Error fetchUsageForecasts1: ", err)
+		return forecastsMap, forecasts, err
+	}
+
+	for rows.Next() {
+		forecast := UsageForecast{weeklyUsage: make([]float64, forecastWeeks)}
+		if err := rows.Scan(&forecast.customerName, &forecast.stockID, &forecast.onHand); err != nil {
+			rows.Close()
+			log.Println("Error fetchUsageForecasts2: ", err)
+			return forecastsMap, forecasts, err
+		}
+		forecastsMap[getForecastKey(forecast.customerName, forecast.stockID)] = &forecast
+		forecasts = append(forecasts, &forecast)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT COALESCE(c.name, ''), tl.stock_id,
+			   (date_trunc('week', NOW())::date - date_trunc('week', tl.updated_at)::date) / 7 AS weeks_ago,
+			   -SUM(tl.quantity_change) AS usage
+		FROM transactions_log tl
+		LEFT JOIN customers c ON c.customer_id = tl.customer_id
+		WHERE
+			($1 = 0 OR tl.customer_id = $1) AND
+			tl.transaction_type = 'Usage' AND
+			tl.updated_at >= date_trunc('week', NOW()) - $2::int * INTERVAL '1 week' AND
+			tl.updated_at < date_trunc('week', NOW())
+		GROUP BY 1, 2, 3;`, customerID, forecastWeeks)
+	if err != nil {
+		log.Println("Error fetchUsageForecasts3: ", err)
+		return forecastsMap, forecasts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var customerName, stockID string
+		var weeksAgo int
+		var usage float64
+		if err := rows.Scan(&customerName, &stockID, &weeksAgo, &usage); err != nil {
+			log.Println("Error fetchUsageForecasts4: ", err)
+			return forecastsMap, forecasts, err
+		}
+
+		forecast, ok := forecastsMap[getForecastKey(customerName, stockID)]
+		if !ok || weeksAgo < 1 || weeksAgo > forecastWeeks {
+			continue
+		}
+		forecast.weeklyUsage[forecastWeeks-weeksAgo] += usage
+	}
+
+	for _, forecast := range forecasts {
+		forecast.calculate()
+	}
+
+	return forecastsMap, forecasts, rows.Err()
+}
+
+func getForecastKey(customerName string, stockID string) string {
+	return customerName + "|" + stockID
+}
+
+// Moving average and exponential smoothing of the weekly usage
+func (f *UsageForecast) calculate() {
+	var total float64
+	for i, usage := range f.weeklyUsage {
+		total += usage
+		if i == 0 {
+			f.smoothedWeekly = usage
+		} else {
+			f.smoothedWeekly = forecastAlpha*usage + (1-forecastAlpha)*f.smoothedWeekly
+		}
+	}
+	f.avgWeekly = total / float64(len(f.weeklyUsage))
+}
+
+func (f *UsageForecast) getDailyUsage() float64 {
+	return f.smoothedWeekly / 7
+}
+
+// Days of supply remaining, false when there is no usage
+func (f *UsageForecast) getDaysOfSupply() (float64, bool) {
+	if f.getDailyUsage() <= 0 {
+		return 0, false
+	}
+
+	return math.Max(float64(f.onHand), 0) / f.getDailyUsage(), true
+}
+
+func (f *UsageForecast) getForecastColumns() []string {
+	daysOfSupply, ok := f.getDaysOfSupply()
+	if !ok {
+		return []string{formatUsage(f.getDailyUsage()), "No usage", ""}
+	}
+
+	stockOut := time.Now().AddDate(0, 0, int(daysOfSupply))
+
+	return []string{
+		formatUsage(f.getDailyUsage()),
+		strconv.Itoa(int(daysOfSupply)),
+		stockOut.Format("1/2/2006"),
+	}
+}
+
+func formatUsage(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+func (f ForecastReport) getReportList() [][]string {
+	fcsList := [][]string{
+		{
+			"Customer", "Stock ID", "On Hand", "Avg Weekly Usage", "Smoothed Weekly Usage",
+			"Daily Usage", "Days of Supply", "Projected Stock-out",
+		},
+	}
+
+	_, forecasts, _ := fetchUsageForecasts(f.db, f.fcsFilter.customerID)
+
+	for _, forecast := range forecasts {
+		fcsList = append(fcsList, append([]string{
+			forecast.customerName,
+			forecast.stockID,
+			strconv.Itoa(forecast.onHand),
+			formatUsage(forecast.avgWeekly),
+			formatUsage(forecast.smoothedWeekly),
+		}, forecast.getForecastColumns()...))
+	}
+
+	return fcsList
+}
+
+func (f ForecastReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, QuantityColumn, NumberColumn, NumberColumn,
+		NumberColumn, NumberColumn, DateColumn,
+	}
+}
+
+func (f ForecastReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Usage Forecast and Days of Supply",
+		customerName: f.fcsFilter.customerName,
+		period:       "Usage of the last " + strconv.Itoa(forecastWeeks) + " weeks",
+	}
+}
+
+func (f ForecastReport) showReport() {
+	customers, _ := fetchCustomers(f.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+
+	dialog := dialog.NewForm("Filter Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer", customerSelector),
+		}, func(confirm bool) {
+			if confirm {
+				f.fcsFilter = SearchFilter{
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+				}
+
+				fcsList := f.getReportList()
+				showReportWindow(f.app, "Usage Forecast", f, fcsList,
+					"forecast_"+time.Now().Format("2006-01-02"), fyne.NewSize(1300, 600))
+			}
+		}, f.window)
+
+	dialog.Resize(fyne.NewSize(500, 150))
+	dialog.Show()
+}
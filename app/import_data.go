@@ -6,6 +6,9 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 func importToDB(db *sql.DB) {
@@ -22,13 +25,27 @@ func importToDB(db *sql.DB) {
 		log.Fatal("Error while reading the file", err)
 	}
 
-	db.Query(`
+	// The tables referencing the customers, items and locations go first,
+	// the report definitions of the old customers and locations as well
+	if _, err := db.Exec(`
+		DELETE FROM kit_components;
+		DELETE FROM kits;
+		DELETE FROM reservations;
+		DELETE FROM jobs;
+		DELETE FROM rate_cards;
+		DELETE FROM ownership_transfers;
 		DELETE FROM transactions_log;
+		DELETE FROM inventory_snapshots;
 		DELETE FROM materials;
+		DELETE FROM item_units;
+		DELETE FROM items;
+		DELETE FROM report_definitions WHERE customer_id IS NOT NULL OR location_id IS NOT NULL;
 		DELETE FROM locations;
 		DELETE FROM customers;
 		DELETE FROM warehouses;
-	`)
+	`); err != nil {
+		log.Fatal("Error while resetting the data", err)
+	}
 
 	for _, record := range records {
 		customerName := record[0]
@@ -44,7 +61,7 @@ func importToDB(db *sql.DB) {
 		maxQty, _ := strconv.Atoi(record[10])
 		isActive, _ := strconv.ParseBool(record[11])
 		owner := record[12]
-		unitCost, _ := strconv.ParseFloat(record[13], 64)
+		unitCost, _ := decimal.NewFromString(strings.TrimSpace(record[13]))
 
 		// Check for a customer
 		var customerId int
@@ -92,26 +109,40 @@ func importToDB(db *sql.DB) {
 				Scan(&locationId)
 		}
 
+		// Check for an item
+		var itemId int
+		db.QueryRow(`
+			INSERT INTO items(
+					customer_id,stock_id,material_type,description,
+					min_required_quantity,max_required_quantity,is_active,default_owner)
+			VALUES($1,$2,$3,$4,$5,$6,$7,$8)
+			ON CONFLICT (customer_id, stock_id) DO UPDATE SET stock_id = EXCLUDED.stock_id
+			RETURNING item_id`,
+			customerId, stockID, materialType, description,
+			minQty, maxQty, isActive, owner).
+			Scan(&itemId)
+
 		var materialId int
 		db.QueryRow(`
 			INSERT INTO materials(
-					stock_id,location_id,customer_id,material_type,
-					description,notes,quantity,min_required_quantity,
-					max_required_quantity,updated_at,is_active,cost,owner)
-			VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,NOW(),$10,$11,$12)
+					item_id,stock_id,location_id,customer_id,
+					notes,quantity,updated_at,cost,owner)
+			VALUES($1,$2,$3,$4,$5,$6,NOW(),$7,$8)
 			RETURNING material_id`,
-			stockID, locationId, customerId, materialType,
-			description, notes, qty, minQty, maxQty, isActive,
-			unitCost, owner).
+			itemId, stockID, locationId, customerId,
+			notes, qty, unitCost, owner).
 			Scan(&materialId)
 
 		db.Query(`
 			INSERT INTO transactions_log(
 									 material_id,stock_id,quantity_change,
-									 notes,cost,job_ticket,updated_at,remaining_quantity
+									 notes,cost,job_ticket,updated_at,remaining_quantity,
+									 transaction_type,location_id,warehouse_id,
+									 customer_id,owner,material_type
 									 	)
-			VALUES($1,$2,$3,$4,$5,$6,NOW(),$7)`,
-			materialId, stockID, qty, notes, unitCost, "job_ticket", qty,
+			VALUES($1,$2,$3,$4,$5,$6,NOW(),$7,$8,$9,$10,$11,$12,$13)`,
+			materialId, stockID, qty, notes, unitCost, "job_ticket", qty, adjustmentTrx,
+			locationId, warehouseId, customerId, owner, materialType,
 		)
 
 		log.Println("job done for material id", materialId)
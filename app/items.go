@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+)
+
+// Quantities are counted in the unit of the item unless it is set
+const defaultItemUnit = "each"
+
+// Catalog entry of a customer stock ID, the stock rows keep only
+// the quantities of the item in the locations
+type Item struct {
+	id           int
+	customerID   int
+	customerName string
+	stockID      string
+	materialType string
+	description  string
+	unit         string
+	minQty       int
+	maxQty       int
+	isActive     bool
+	defaultOwner string
+}
+
+// Items of a customer, all customers when customerID is 0
+func fetchItems(db *sql.DB, customerID int) ([]Item, error) {
+	rows, err := db.Query(`
+		SELECT i.item_id, i.customer_id, c.name, i.stock_id, i.material_type,
+			COALESCE(i.description, ''), i.unit,
+			COALESCE(i.min_required_quantity, 0), COALESCE(i.max_required_quantity, 0),
+			i.is_active, i.default_owner
+		FROM items i
+		JOIN customers c ON c.customer_id = i.customer_id
+		WHERE ($1 = 0 OR i.customer_id = $1)
+		ORDER BY c.name, i.stock_id;`, customerID)
+	if err != nil {
+		log.Println("Error fetchItems1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []Item
+
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(&i.id, &i.customerID, &i.customerName, &i.stockID, &i.materialType,
+			&i.description, &i.unit, &i.minQty, &i.maxQty, &i.isActive, &i.defaultOwner); err != nil {
+			log.Println("Error fetchItems2: ", err)
+			return items, err
+		}
+		items = append(items, i)
+	}
+
+	return items, rows.Err()
+}
+
+func (i Item) validate() error {
+	if i.customerID == 0 || strings.TrimSpace(i.stockID) == "" || i.materialType == "" {
+		return errors.New("The customer, the stock ID and the type of the item are required")
+	}
+	if i.minQty < 0 || i.maxQty < 0 || (i.maxQty > 0 && i.minQty > i.maxQty) {
+		return errors.New("The min quantity cannot be more than the max quantity")
+	}
+
+	return nil
+}
+
+// Insert a new item or change the existing one, the ID of the item is returned
+func saveItem(db *sql.DB, i Item) (int, error) {
+	if err := i.validate(); err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(i.unit) == "" {
+		i.unit = defaultItemUnit
+	}
+
+	var err error
+	if i.id == 0 {
+		err = db.QueryRow(`
+			INSERT INTO items (customer_id, stock_id, material_type, description, unit,
+				min_required_quantity, max_required_quantity, is_active, default_owner)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING item_id;`,
+			i.customerID, strings.TrimSpace(i.stockID), i.materialType, i.description, i.unit,
+			i.minQty, i.maxQty, i.isActive, i.defaultOwner).Scan(&i.id)
+	} else {
+		// The stock ID is the key of the stock rows and the history, it is not changed
+		_, err = db.Exec(`
+			UPDATE items
+			SET material_type = $1, description = $2, unit = $3, min_required_quantity = $4,
+				max_required_quantity = $5, is_active = $6, default_owner = $7
+			WHERE item_id = $8;`,
+			i.materialType, i.description, i.unit, i.minQty, i.maxQty, i.isActive, i.defaultOwner, i.id)
+	}
+	if err != nil {
+		log.Println("Error saveItem: ", err)
+		if strings.Contains(err.Error(), "items_customer_stock") {
+			return 0, errors.New("The customer already has the stock ID " + i.stockID)
+		}
+		return 0, err
+	}
+
+	return i.id, nil
+}
+
+// Item of an incoming material, the shipments sent before the catalog
+// add their items when they are accepted
+func ensureItem(db queryExecutor, materialOpts *MaterialOpts) (int, error) {
+	var itemID int
+
+	err := db.QueryRow(`
+		INSERT INTO items (customer_id, stock_id, material_type, description,
+			min_required_quantity, max_required_quantity, is_active, default_owner)
+		SELECT customer_id, $2, $3, $4, $5, $6, $7, $8
+		FROM customers
+		WHERE name = $1
+		ON CONFLICT (customer_id, stock_id) DO UPDATE SET stock_id = EXCLUDED.stock_id
+		RETURNING item_id;`,
+		materialOpts.customerName, materialOpts.stockID, materialOpts.materialType, materialOpts.notes,
+		materialOpts.minQty, materialOpts.maxQty, materialOpts.isActive, materialOpts.owner,
+	).Scan(&itemID)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("unknown customer " + materialOpts.customerName)
+	}
+	if err != nil {
+		log.Println("Error ensureItem: ", err)
+		return 0, err
+	}
+
+	return itemID, nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+// Statuses of the jobs, closed jobs are locked against postings
+const (
+	jobOpen   = "Open"
+	jobClosed = "Closed"
+)
+
+var jobStatuses = []string{jobOpen, jobClosed}
+
+type Job struct {
+	id           int
+	jobTicket    string
+	customerID   int
+	customerName string
+	description  string
+	status       string
+	createdAt    time.Time
+	dueDate      sql.NullTime
+	closedAt     sql.NullTime
+}
+
+type JobCostReport struct {
+	Report
+	jobFilter SearchFilter
+}
+
+// Jobs of a customer with a status, all of them when the filter is empty
+func fetchJobs(db *sql.DB, customerID int, status string) ([]Job, error) {
+	rows, err := db.Query(`
+		SELECT j.job_id, j.job_ticket, COALESCE(j.customer_id, 0), COALESCE(c.name, ''),
+			COALESCE(j.description, ''), j.status, j.created_at, j.due_date, j.closed_at
+		FROM jobs j
+		LEFT JOIN customers c ON c.customer_id = j.customer_id
+		WHERE ($1 = 0 OR j.customer_id = $1) AND ($2 = '' OR j.status = $2)
+		ORDER BY j.status DESC, j.due_date NULLS LAST, j.job_ticket;`,
+		customerID, status)
+	if err != nil {
+		log.Println("Error fetchJobs1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.id, &j.jobTicket, &j.customerID, &j.customerName,
+			&j.description, &j.status, &j.createdAt, &j.dueDate, &j.closedAt); err != nil {
+			log.Println("Error fetchJobs2: ", err)
+			return jobs, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Tickets of the open jobs of a customer for the job selectors
+func fetchOpenJobTickets(db *sql.DB, customerID int) []string {
+	jobs, _ := fetchJobs(db, customerID, jobOpen)
+
+	var tickets []string
+	for _, j := range jobs {
+		tickets = append(tickets, j.jobTicket)
+	}
+
+	return tickets
+}
+
+// Error unless the job ticket is an open job
+func checkJobOpen(db queryExecutor, jobTicket string) error {
+	if strings.TrimSpace(jobTicket) == "" {
+		return errors.New("The job ticket is required")
+	}
+
+	var status string
+	err := db.QueryRow(`SELECT status FROM jobs WHERE job_ticket = $1;`, jobTicket).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.New("The job " + jobTicket + " does not exist, add it in Jobs first")
+	}
+	if err != nil {
+		log.Println("Error checkJobOpen: ", err)
+		return err
+	}
+	if status != jobOpen {
+		return errors.New("The job " + jobTicket + " is closed")
+	}
+
+	return nil
+}
+
+// Insert a new job or update the existing one
+func saveJob(db *sql.DB, j Job) error {
+	if strings.TrimSpace(j.jobTicket) == "" || j.customerID == 0 {
+		return errors.New("The job ticket and the customer are required")
+	}
+
+	var err error
+	if j.id == 0 {
+		_, err = db.Exec(`INSERT INTO jobs (job_ticket, customer_id, description, due_date)
+						  VALUES ($1, $2, $3, $4);`,
+			j.jobTicket, j.customerID, nullString(j.description), j.dueDate)
+	} else {
+		_, err = db.Exec(`UPDATE jobs SET job_ticket = $1, customer_id = $2, description = $3, due_date = $4
+						  WHERE job_id = $5 AND status = $6;`,
+			j.jobTicket, j.customerID, nullString(j.description), j.dueDate, j.id, jobOpen)
+	}
+	if err != nil {
+		log.Println("Error saveJob: ", err)
+	}
+
+	return err
+}
+
+// Close a job and release its open reservations
+func closeJob(db *sql.DB, j Job) error {
+	return withTransaction(db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`UPDATE jobs SET status = $1, closed_at = NOW() WHERE job_id = $2;`,
+			jobClosed, j.id); err != nil {
+			log.Println("Error closeJob1: ", err)
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM reservations WHERE job_ticket = $1 AND consumed_quantity = 0;`,
+			j.jobTicket); err != nil {
+			log.Println("Error closeJob2: ", err)
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE reservations SET quantity = consumed_quantity WHERE job_ticket = $1;`,
+			j.jobTicket); err != nil {
+			log.Println("Error closeJob3: ", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func reopenJob(db *sql.DB, j Job) error {
+	if _, err := db.Exec(`UPDATE jobs SET status = $1, closed_at = NULL WHERE job_id = $2;`,
+		jobOpen, j.id); err != nil {
+		log.Println("Error reopenJob: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Used quantities and FIFO costs by job, stock ID and material type
+func (r JobCostReport) getReportList() [][]string {
+	rows, err := r.db.Query(`
+		SELECT j.job_ticket, COALESCE(c.name, ''), j.status, tl.stock_id,
+			COALESCE(tl.material_type::TEXT, ''),
+			SUM(-tl.quantity_change), SUM(-tl.quantity_change * tl.cost)
+		FROM jobs j
+		JOIN transactions_log tl ON tl.job_ticket = j.job_ticket
+		LEFT JOIN customers c ON c.customer_id = j.customer_id
+		WHERE tl.transaction_type = $1 AND
+			($2 = '' OR j.job_ticket = $2) AND
+			($3 = 0 OR j.customer_id = $3) AND
+			($4 = '' OR j.status = $4) AND
+			($5::timestamp IS NULL OR tl.updated_at >= $5) AND
+			($6::timestamp IS NULL OR tl.updated_at < $6)
+		GROUP BY j.job_ticket, c.name, j.status, tl.stock_id, 5
+		ORDER BY j.job_ticket, tl.stock_id;`,
+		usageTrx, r.jobFilter.jobTicket, r.jobFilter.customerID, r.jobFilter.status,
+		toNullTime(r.jobFilter.dateFrom), toNullTime(getDayAfter(r.jobFilter.dateTo)))
+	if err != nil {
+		log.Println("Error JobCostReport1: ", err)
+	}
+
+	jobList := [][]string{
+		{"Job Ticket", "Customer", "Status", "Stock ID", "Material Type", "Quantity", "Cost, USD"},
+	}
+	if err != nil {
+		return jobList
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var jobTicket, customerName, status, stockID, materialType string
+		var quantity int
+		var cost decimal.Decimal
+		if err := rows.Scan(&jobTicket, &customerName, &status, &stockID, &materialType,
+			&quantity, &cost); err != nil {
+			log.Println("Error JobCostReport2: ", err)
+			continue
+		}
+
+		jobList = append(jobList, []string{
+			jobTicket, customerName, status, stockID, materialType,
+			strconv.Itoa(quantity), formatMoney(cost),
+		})
+	}
+
+	return jobList
+}
+
+func (r JobCostReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, TextColumn, TextColumn, TextColumn, QuantityColumn, ValueColumn,
+	}
+}
+
+func (r JobCostReport) getReportHeader() ReportHeader {
+	period := "All dates"
+	if !r.jobFilter.dateFrom.IsZero() || !r.jobFilter.dateTo.IsZero() {
+		period = formatPeriod(r.jobFilter.dateFrom, r.jobFilter.dateTo)
+	}
+
+	return ReportHeader{
+		title:        "Job Costing",
+		customerName: r.jobFilter.customerName,
+		period:       period,
+	}
+}
+
+func (r JobCostReport) showReport() {
+	customers, _ := fetchCustomers(r.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	jobTicketInput := widget.NewEntry()
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	statusSelector := widget.NewSelect(jobStatuses, func(s string) {})
+	dateFromEntry := newDateEntry(r.window)
+	dateToEntry := newDateEntry(r.window)
+	rangeSelector := newQuickRangeSelector(dateFromEntry, dateToEntry)
+
+	dialog := dialog.NewForm("Filter Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Job Ticket", jobTicketInput),
+			widget.NewFormItem("Customer", customerSelector),
+			widget.NewFormItem("Status", statusSelector),
+			widget.NewFormItem("Used", rangeSelector),
+			widget.NewFormItem("Date From", dateFromEntry),
+			widget.NewFormItem("Date To", dateToEntry),
+		}, func(confirm bool) {
+			if confirm {
+				from, to, err := getDateRange(dateFromEntry, dateToEntry)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), r.window)
+					return
+				}
+
+				r.jobFilter = SearchFilter{
+					jobTicket:    strings.TrimSpace(jobTicketInput.Text),
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+					status:       statusSelector.Selected,
+					dateFrom:     from,
+					dateTo:       to,
+				}
+
+				jobList := r.getReportList()
+				showReportWindow(r.app, "Job Costing", r, jobList,
+					"job_costing_"+time.Now().Format("2006-01-02"), fyne.NewSize(1100, 600))
+			}
+		}, r.window)
+
+	dialog.Resize(fyne.NewSize(600, 350))
+	dialog.Show()
+}
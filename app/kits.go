@@ -0,0 +1,325 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Bill of materials of a customer product
+type Kit struct {
+	id           int
+	customerID   int
+	customerName string
+	name         string
+	description  string
+	components   []KitComponent
+}
+
+type KitComponent struct {
+	stockID         string
+	quantityPerUnit int
+}
+
+// Required and available quantities of a component for a job
+type ComponentAvailability struct {
+	stockID   string
+	required  int
+	available int
+}
+
+// Issued components of a job with the cost of the used cost layers
+type JobRun struct {
+	jobTicket string
+	pieces    int
+	issued    []ComponentAvailability
+	cost      decimal.Decimal
+}
+
+func fetchKits(db *sql.DB, customerID int) ([]Kit, error) {
+	rows, err := db.Query(`
+		SELECT k.kit_id, k.customer_id, c.name, k.name, COALESCE(k.description, '')
+		FROM kits k
+		JOIN customers c ON c.customer_id = k.customer_id
+		WHERE ($1 = 0 OR k.customer_id = $1)
+		ORDER BY c.name, k.name;`, customerID)
+	if err != nil {
+		log.Println("Error fetchKits1: ", err)
+		return nil, err
+	}
+
+	var kits []Kit
+	for rows.Next() {
+		var kit Kit
+		if err := rows.Scan(&kit.id, &kit.customerID, &kit.customerName, &kit.name, &kit.description); err != nil {
+			rows.Close()
+			log.Println("Error fetchKits2: ", err)
+			return kits, err
+		}
+		kits = append(kits, kit)
+	}
+	rows.Close()
+
+	for i := range kits {
+		if kits[i].components, err = fetchKitComponents(db, kits[i].id); err != nil {
+			return kits, err
+		}
+	}
+
+	return kits, nil
+}
+
+func fetchKitComponents(db queryExecutor, kitID int) ([]KitComponent, error) {
+	rows, err := db.Query(`
+		SELECT stock_id, quantity_per_unit
+		FROM kit_components
+		WHERE kit_id = $1
+		ORDER BY stock_id;`, kitID)
+	if err != nil {
+		log.Println("Error fetchKitComponents1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var components []KitComponent
+	for rows.Next() {
+		var component KitComponent
+		if err := rows.Scan(&component.stockID, &component.quantityPerUnit); err != nil {
+			log.Println("Error fetchKitComponents2: ", err)
+			return components, err
+		}
+		components = append(components, component)
+	}
+
+	return components, rows.Err()
+}
+
+// Stock IDs of a customer for the component selectors
+func fetchCustomerStockIDs(db *sql.DB, customerID int) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT stock_id FROM items WHERE customer_id = $1
+		UNION
+		SELECT DISTINCT i.stock_id FROM incoming_materials i
+		JOIN customers c ON c.name = i.customer_name
+		WHERE c.customer_id = $1
+		ORDER BY 1;`, customerID)
+	if err != nil {
+		log.Println("Error fetchCustomerStockIDs1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stockIDs []string
+	for rows.Next() {
+		var stockID string
+		if err := rows.Scan(&stockID); err != nil {
+			log.Println("Error fetchCustomerStockIDs2: ", err)
+			return stockIDs, err
+		}
+		stockIDs = append(stockIDs, stockID)
+	}
+
+	return stockIDs, rows.Err()
+}
+
+func (k Kit) validate() error {
+	if k.customerID == 0 || strings.TrimSpace(k.name) == "" {
+		return errors.New("The customer and the name of the kit are required")
+	}
+	if len(k.components) == 0 {
+		return errors.New("The kit has no components")
+	}
+
+	stockIDs := make(map[string]bool)
+	for _, component := range k.components {
+		if component.stockID == "" || component.quantityPerUnit <= 0 {
+			return errors.New("Every component needs a stock ID and a positive quantity per unit")
+		}
+		if stockIDs[component.stockID] {
+			return errors.New("The component " + component.stockID + " is listed twice")
+		}
+		stockIDs[component.stockID] = true
+	}
+
+	return nil
+}
+
+// Insert a new kit or replace the existing one with its components
+func saveKit(db *sql.DB, k Kit) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+
+	return withTransaction(db, func(tx *sql.Tx) error {
+		var err error
+		if k.id == 0 {
+			err = tx.QueryRow(`INSERT INTO kits (customer_id, name, description)
+							   VALUES ($1, $2, $3) RETURNING kit_id;`,
+				k.customerID, k.name, k.description).Scan(&k.id)
+		} else {
+			_, err = tx.Exec(`UPDATE kits SET customer_id = $1, name = $2, description = $3
+							  WHERE kit_id = $4;`,
+				k.customerID, k.name, k.description, k.id)
+		}
+		if err != nil {
+			log.Println("Error saveKit1: ", err)
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM kit_components WHERE kit_id = $1;`, k.id); err != nil {
+			log.Println("Error saveKit2: ", err)
+			return err
+		}
+
+		for _, component := range k.components {
+			if _, err := tx.Exec(`INSERT INTO kit_components (kit_id, stock_id, quantity_per_unit)
+								  VALUES ($1, $2, $3);`,
+				k.id, component.stockID, component.quantityPerUnit); err != nil {
+				log.Println("Error saveKit3: ", err)
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func deleteKit(db *sql.DB, kitID int) error {
+	if _, err := db.Exec(`DELETE FROM kits WHERE kit_id = $1;`, kitID); err != nil {
+		log.Println("Error deleteKit: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Required quantities of the components for the pieces and the quantities
+// of the customer across all locations
+func getKitAvailability(db queryExecutor, k Kit, pieces int) ([]ComponentAvailability, error) {
+	var availability []ComponentAvailability
+
+	for _, component := range k.components {
+		a := ComponentAvailability{
+			stockID:  component.stockID,
+			required: component.quantityPerUnit * pieces,
+		}
+
+		err := db.QueryRow(`
+			SELECT COALESCE(SUM(m.quantity), 0)
+			FROM materials m
+			JOIN items i ON i.item_id = m.item_id
+			WHERE m.customer_id = $1 AND m.stock_id = $2 AND i.is_active;`,
+			k.customerID, component.stockID).Scan(&a.available)
+		if err != nil {
+			log.Println("Error getKitAvailability: ", err)
+			return availability, err
+		}
+
+		availability = append(availability, a)
+	}
+
+	return availability, nil
+}
+
+// Error with the missing quantities, nil when every component is available
+func checkAvailability(availability []ComponentAvailability) error {
+	var shortages []string
+	for _, a := range availability {
+		if a.available < a.required {
+			shortages = append(shortages,
+				fmt.Sprintf("%s: required %d, available %d", a.stockID, a.required, a.available))
+		}
+	}
+
+	if len(shortages) > 0 {
+		return errors.New("Not enough materials for the job:\n" + strings.Join(shortages, "\n"))
+	}
+
+	return nil
+}
+
+// Issue all components of a kit for the pieces of a job ticket,
+// either every component is used or nothing is changed
+func runKitJob(db *sql.DB, k Kit, jobTicket string, pieces int, notes string) (JobRun, error) {
+	run := JobRun{jobTicket: jobTicket, pieces: pieces}
+
+	if strings.TrimSpace(jobTicket) == "" || pieces <= 0 {
+		return run, errors.New("The job ticket and a positive number of pieces are required")
+	}
+	if len(k.components) == 0 {
+		return run, errors.New("The kit has no components")
+	}
+	if err := checkJobOpen(db, jobTicket); err != nil {
+		return run, err
+	}
+
+	err := withTransaction(db, func(tx *sql.Tx) error {
+		// Consolidated cost of the cost layers used by the job
+		run.cost = decimal.Zero
+
+		for _, component := range k.components {
+			required := component.quantityPerUnit * pieces
+
+			// Locations of the component are locked until the job is issued,
+			// the smaller remains are used first to free the locations
+			rows, err := tx.Query(`
+				SELECT m.material_id, m.quantity
+				FROM materials m
+				JOIN items i ON i.item_id = m.item_id
+				WHERE m.customer_id = $1 AND m.stock_id = $2 AND i.is_active AND m.quantity > 0
+				ORDER BY m.quantity, m.material_id
+				FOR UPDATE OF m;`,
+				k.customerID, component.stockID)
+			if err != nil {
+				log.Println("Error runKitJob1: ", err)
+				return err
+			}
+
+			type materialQuantity struct{ materialID, quantity int }
+			var materials []materialQuantity
+			available := 0
+			for rows.Next() {
+				var m materialQuantity
+				if err := rows.Scan(&m.materialID, &m.quantity); err != nil {
+					rows.Close()
+					log.Println("Error runKitJob2: ", err)
+					return err
+				}
+				materials = append(materials, m)
+				available += m.quantity
+			}
+			rows.Close()
+
+			run.issued = append(run.issued, ComponentAvailability{
+				stockID:   component.stockID,
+				required:  required,
+				available: available,
+			})
+			if available < required {
+				continue
+			}
+
+			remaining := required
+			for _, m := range materials {
+				if remaining == 0 {
+					break
+				}
+				quantity := min(m.quantity, remaining)
+				_, value, err := issueStock(tx, m.materialID, quantity, jobTicket, notes)
+				if err != nil {
+					return errors.New(component.stockID + ": " + err.Error())
+				}
+				run.cost = run.cost.Add(value)
+				remaining -= quantity
+			}
+		}
+
+		return checkAvailability(run.issued)
+	})
+
+	return run, err
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/jung-kurt/gofpdf/contrib/barcode"
+)
+
+const (
+	code128Barcode = "Code128"
+	qrBarcode      = "QR"
+)
+
+var barcodeTypes = []string{code128Barcode, qrBarcode}
+
+const zplFormat = "zpl"
+
+var labelFormats = []string{pdfFormat, zplFormat}
+
+const (
+	labelMargin    = 2.0 // mm
+	zplDotsPerMM   = 8   // 203 dpi thermal printers
+	mmPerPoint     = 25.4 / 72
+	locationPrefix = "LOC-"
+	stockPrefix    = "STK-"
+)
+
+type LabelTemplate struct {
+	id          int
+	name        string
+	widthMM     float64
+	heightMM    float64
+	barcodeType string
+	fontSize    float64 // pt of the title, the other lines are smaller
+}
+
+// Content of a label: the scanned code, a bold title and the text lines
+type Label struct {
+	code  string
+	title string
+	lines []string
+}
+
+// The code of a location label
+func getLocationCode(locationID int) string {
+	return locationPrefix + strconv.Itoa(locationID)
+}
+
+// The code of a material label: the item of the customer and the owner
+func getStockCode(itemID int, owner string) string {
+	return stockPrefix + strconv.Itoa(itemID) + "-" + owner
+}
+
+func fetchLabelTemplates(db *sql.DB) ([]LabelTemplate, error) {
+	rows, err := db.Query(`SELECT template_id, name, width_mm, height_mm, barcode_type, font_size
+						   FROM label_templates ORDER BY name;`)
+	if err != nil {
+		log.Println("Error fetchLabelTemplates1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []LabelTemplate
+
+	for rows.Next() {
+		var t LabelTemplate
+		if err := rows.Scan(&t.id, &t.name, &t.widthMM, &t.heightMM, &t.barcodeType, &t.fontSize); err != nil {
+			log.Println("Error fetchLabelTemplates2: ", err)
+			return templates, err
+		}
+		templates = append(templates, t)
+	}
+	if err = rows.Err(); err != nil {
+		return templates, err
+	}
+
+	return templates, nil
+}
+
+// Insert a new template or update the existing one
+func saveLabelTemplate(db *sql.DB, t LabelTemplate) error {
+	var err error
+	if t.id == 0 {
+		_, err = db.Exec(`INSERT INTO label_templates (name, width_mm, height_mm, barcode_type, font_size)
+						  VALUES ($1, $2, $3, $4, $5);`,
+			t.name, t.widthMM, t.heightMM, t.barcodeType, t.fontSize)
+	} else {
+		_, err = db.Exec(`UPDATE label_templates
+						  SET name = $1, width_mm = $2, height_mm = $3, barcode_type = $4, font_size = $5
+						  WHERE template_id = $6;`,
+			t.name, t.widthMM, t.heightMM, t.barcodeType, t.fontSize, t.id)
+	}
+	if err != nil {
+		log.Println("Error saveLabelTemplate: ", err)
+	}
+
+	return err
+}
+
+// Labels of all locations of a warehouse
+func fetchLocationLabels(db *sql.DB, warehouseID int) ([]Label, error) {
+	rows, err := db.Query(`
+		SELECT l.location_id, l.name, w.name
+		FROM locations l
+		JOIN warehouses w ON w.warehouse_id = l.warehouse_id
+		WHERE l.warehouse_id = $1
+		ORDER BY l.name;`, warehouseID)
+	if err != nil {
+		log.Println("Error fetchLocationLabels1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []Label
+
+	for rows.Next() {
+		var locationID int
+		var locationName, warehouseName string
+		if err := rows.Scan(&locationID, &locationName, &warehouseName); err != nil {
+			log.Println("Error fetchLocationLabels2: ", err)
+			return labels, err
+		}
+		labels = append(labels, Label{
+			code:  getLocationCode(locationID),
+			title: locationName,
+			lines: []string{"Warehouse: " + warehouseName},
+		})
+	}
+
+	return labels, rows.Err()
+}
+
+// Labels of the materials stored in a warehouse, one per stock ID, customer and owner
+func fetchMaterialLabels(db *sql.DB, warehouseID int) ([]Label, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT m.item_id, m.stock_id, COALESCE(c.name, ''), m.owner, i.material_type
+		FROM materials m
+		JOIN items i ON i.item_id = m.item_id
+		JOIN locations l ON l.location_id = m.location_id
+		LEFT JOIN customers c ON c.customer_id = m.customer_id
+		WHERE l.warehouse_id = $1 AND m.quantity > 0
+		ORDER BY 3, 2, 4;`, warehouseID)
+	if err != nil {
+		log.Println("Error fetchMaterialLabels1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []Label
+
+	for rows.Next() {
+		var itemID int
+		var stockID, customerName, owner, materialType string
+		if err := rows.Scan(&itemID, &stockID, &customerName, &owner, &materialType); err != nil {
+			log.Println("Error fetchMaterialLabels2: ", err)
+			return labels, err
+		}
+		labels = append(labels, Label{
+			code:  getStockCode(itemID, owner),
+			title: stockID,
+			lines: []string{customerName, materialType + " / Owner: " + owner},
+		})
+	}
+
+	return labels, rows.Err()
+}
+
+func writeLabels(w io.Writer, labels []Label, t LabelTemplate, format string) error {
+	if len(labels) == 0 {
+		return errors.New("there are no labels to print")
+	}
+
+	switch format {
+	case pdfFormat:
+		return writeLabelsPDF(w, labels, t)
+	case zplFormat:
+		return writeLabelsZPL(w, labels, t)
+	default:
+		return errors.New("unsupported label format: " + format)
+	}
+}
+
+// One label per page of the template size
+func writeLabelsPDF(w io.Writer, labels []Label, t LabelTemplate) error {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		UnitStr:        "mm",
+		OrientationStr: "P",
+		Size:           gofpdf.SizeType{Wd: t.widthMM, Ht: t.heightMM},
+	})
+	pdf.SetMargins(labelMargin, labelMargin, labelMargin)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	titleHeight := t.fontSize * mmPerPoint * 1.2
+	lineFontSize := t.fontSize * 0.7
+	lineHeight := lineFontSize * mmPerPoint * 1.2
+
+	for _, label := range labels {
+		pdf.AddPage()
+
+		textX := labelMargin
+		textWidth := t.widthMM - 2*labelMargin
+
+		if t.barcodeType == qrBarcode {
+			side := t.heightMM - 2*labelMargin
+			key := barcode.RegisterQR(pdf, label.code, qr.M, qr.Auto)
+			barcode.Barcode(pdf, key, labelMargin, labelMargin, side, side, false)
+			textX += side + labelMargin
+			textWidth -= side + labelMargin
+		} else {
+			barcodeHeight := t.heightMM * 0.4
+			key := barcode.RegisterCode128(pdf, label.code)
+			barcode.Barcode(pdf, key, labelMargin, t.heightMM-labelMargin-barcodeHeight-lineHeight,
+				t.widthMM-2*labelMargin, barcodeHeight, false)
+
+			pdf.SetFont("Arial", "", lineFontSize)
+			pdf.SetXY(labelMargin, t.heightMM-labelMargin-lineHeight)
+			pdf.CellFormat(t.widthMM-2*labelMargin, lineHeight, tr(label.code), "", 0, "C", false, 0, "")
+		}
+
+		pdf.SetFont("Arial", "B", t.fontSize)
+		pdf.SetXY(textX, labelMargin)
+		pdf.CellFormat(textWidth, titleHeight, tr(fitPDFText(pdf, label.title, textWidth)), "", 2, "L", false, 0, "")
+
+		pdf.SetFont("Arial", "", lineFontSize)
+		for _, line := range label.lines {
+			pdf.SetX(textX)
+			pdf.CellFormat(textWidth, lineHeight, tr(fitPDFText(pdf, line, textWidth)), "", 2, "L", false, 0, "")
+		}
+	}
+
+	return pdf.Output(w)
+}
+
+// ZPL II commands of the labels for Zebra compatible thermal printers
+func writeLabelsZPL(w io.Writer, labels []Label, t LabelTemplate) error {
+	dots := func(mm float64) int {
+		return int(mm * zplDotsPerMM)
+	}
+	// ^ and ~ start the ZPL commands
+	escape := strings.NewReplacer("^", " ", "~", " ").Replace
+
+	margin := dots(labelMargin)
+	titleDots := dots(t.fontSize * mmPerPoint)
+	lineDots := dots(t.fontSize * 0.7 * mmPerPoint)
+
+	var zpl strings.Builder
+
+	for _, label := range labels {
+		fmt.Fprintf(&zpl, "^XA\n^CI28\n^PW%d\n^LL%d\n", dots(t.widthMM), dots(t.heightMM))
+
+		textX := margin
+		if t.barcodeType == qrBarcode {
+			side := dots(t.heightMM) - 2*margin
+			magnification := min(max(side/35, 1), 10)
+			fmt.Fprintf(&zpl, "^FO%d,%d^BQN,2,%d^FDQA,%s^FS\n", margin, margin, magnification, escape(label.code))
+			textX += side + margin
+		} else {
+			barcodeHeight := dots(t.heightMM * 0.4)
+			fmt.Fprintf(&zpl, "^FO%d,%d^BY2^BCN,%d,Y,N,N^FD%s^FS\n",
+				margin, dots(t.heightMM)-margin-barcodeHeight-lineDots*3/2, barcodeHeight, escape(label.code))
+		}
+
+		y := margin
+		fmt.Fprintf(&zpl, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", textX, y, titleDots, titleDots, escape(label.title))
+		y += titleDots * 6 / 5
+		for _, line := range label.lines {
+			fmt.Fprintf(&zpl, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", textX, y, lineDots, lineDots, escape(line))
+			y += lineDots * 6 / 5
+		}
+
+		zpl.WriteString("^XZ\n")
+	}
+
+	_, err := io.WriteString(w, zpl.String())
+	return err
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SMTP settings of the report emails
+type MailConfig struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     string
+}
+
+// Read the SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASSWORD and SMTP_FROM variables
+func getMailConfig() (MailConfig, error) {
+	config := MailConfig{
+		host:     os.Getenv("SMTP_HOST"),
+		port:     os.Getenv("SMTP_PORT"),
+		user:     os.Getenv("SMTP_USER"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     os.Getenv("SMTP_FROM"),
+	}
+
+	if config.host == "" {
+		return config, errors.New("email is not configured, set SMTP_HOST")
+	}
+	if config.port == "" {
+		config.port = "587"
+	}
+	if config.from == "" {
+		config.from = config.user
+	}
+
+	return config, nil
+}
+
+// Send the report files as attachments of one email
+func sendReportEmail(recipients []string, reportName string, files []string) error {
+	config, err := getMailConfig()
+	if err != nil {
+		return err
+	}
+
+	var to []string
+	for _, recipient := range recipients {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			to = append(to, recipient)
+		}
+	}
+	if len(to) == 0 {
+		return errors.New("no email recipients")
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	header := "From: " + config.from + "\r\n" +
+		"To: " + strings.Join(to, ", ") + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", companyName+": "+reportName) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=" + writer.Boundary() + "\r\n\r\n"
+
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=utf-8"},
+	})
+	if err != nil {
+		return err
+	}
+	textPart.Write([]byte("The report \"" + reportName + "\" is attached.\r\n"))
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Base(file)
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {"attachment; filename=\"" + name + "\""},
+		})
+		if err != nil {
+			return err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(content)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if config.user != "" {
+		auth = smtp.PlainAuth("", config.user, config.password, config.host)
+	}
+
+	return smtp.SendMail(config.host+":"+config.port, auth, config.from, to,
+		append([]byte(header), body.Bytes()...))
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"flag"
 	"log"
-	"strconv"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -15,7 +18,16 @@ import (
 )
 
 func main() {
-	myApp := app.New()
+	headless := flag.Bool("headless", false, "run the scheduled reports without the user interface")
+	runReport := flag.String("run-report", "", "generate the saved report with the given name and exit")
+	flag.Parse()
+
+	if *headless || *runReport != "" {
+		runHeadless(*runReport)
+		return
+	}
+
+	myApp := app.NewWithID("com.tagsystems.inventory")
 	myApp.Settings().SetTheme(theme.LightTheme())
 	myWindow := myApp.NewWindow("Tag Systems USA Inventory Management v1.2")
 
@@ -27,6 +39,21 @@ func main() {
 		myWindow.Resize(fyne.NewSize(100, 100))
 		myWindow.ShowAndRun()
 	} else {
+		// Windows refresh themselves when the stock is changed
+		changes, err = startChangeNotifier()
+		if err != nil {
+			log.Println("Error starting change notifications: ", err)
+		}
+		defer changes.close()
+
+		// Scheduled reports run in the background while the app is open
+		scheduler := newReportScheduler(db)
+		if err := scheduler.reload(); err != nil {
+			log.Println("Error loading report schedules: ", err)
+		}
+		defer scheduler.stop()
+		startInventorySnapshots(db)
+
 		mainLabel := widget.NewLabel("Main Menu")
 		mainLabel.TextStyle.Bold = true
 		mainLabel.Alignment = fyne.TextAlignCenter
@@ -38,35 +65,30 @@ func main() {
 		customerContainer := container.New(layout.NewCustomPaddedVBoxLayout(10),
 			customerLabel,
 			widget.NewButton("Add Customer", func() { addCustomer(myWindow, db) }),
+			widget.NewButton("Item Catalog", func() { showItems(myApp, db) }),
 			widget.NewButton("Send Material", func() { sendMaterial(myWindow, db) }),
 			widget.NewButton("Import Materials", func() { importToDB(db) }),
+			widget.NewButton("Jobs", func() { showJobs(myApp, db) }),
+			widget.NewButton("Rate Cards", func() { showRateCards(myWindow, db) }),
+			widget.NewButton("Change Ownership", func() { changeOwnership(myApp, myWindow, db) }),
+			widget.NewButton("Reserve Material", func() { reserveMaterial(myWindow, db) }),
+			widget.NewButton("Cancel Reservation", func() { releaseReservation(myWindow, db) }),
 		)
 
-		// Incoming materials data
-		incomingMaterialsData := binding.NewString()
-		str := "Incoming Materials: "
-		incomingMaterialsData.Set(str + strconv.Itoa(getIncomingMaterialsNumber(db)))
-		incomingMaterialsLabel := widget.NewLabelWithData(incomingMaterialsData)
-		incomingMaterialsLabel.Alignment = fyne.TextAlignCenter
-		incomingMaterialsLabel.TextStyle.Bold = true
-
 		warehouseLabel := widget.NewLabel("Warehouse")
 		warehouseLabel.TextStyle.Bold = true
 		warehouseLabel.Alignment = fyne.TextAlignCenter
 
 		materialContainer := container.New(layout.NewCustomPaddedVBoxLayout(10),
 			warehouseLabel,
-			widget.NewSeparator(),
-			incomingMaterialsLabel,
-			widget.NewButton("Refresh Data", func() {
-				incomingMaterialsQty := getIncomingMaterialsNumber(db)
-				incomingMaterialsData.Set((str + strconv.Itoa(incomingMaterialsQty)))
-			}),
-			widget.NewSeparator(),
 			widget.NewButton("Add Location", func() { addWarehouse(myWindow, db) }),
+			widget.NewButton("Warehouses and Labels", func() { showWarehouses(myApp, db) }),
 			widget.NewButton("Accept Materials", func() { acceptIncomingMaterials(myApp, db) }),
 			widget.NewButton("Use Material", func() { removeMaterial(myWindow, db) }),
 			widget.NewButton("Move Material to Location", func() { moveMaterial(myWindow, db) }),
+			widget.NewButton("Scanner Mode", func() { showScanner(myApp, db) }),
+			widget.NewButton("Kits", func() { showKits(myApp, db) }),
+			widget.NewButton("Run Job", func() { runJob(myWindow, db, nil) }),
 		)
 
 		reportsLabel := widget.NewLabel("Reports")
@@ -77,14 +99,41 @@ func main() {
 		inv := InventoryReport{Report: report}
 		trx := TransactionReport{Report: report}
 		blc := BalanceReport{Report: report}
+		stm := StatementReport{Report: report}
+		val := ValuationReport{Report: report}
+		agn := AgingReport{Report: report}
+		fcs := ForecastReport{Report: report}
+		res := ReservationReport{Report: report}
+		job := JobCostReport{Report: report}
+		bil := StorageBillingReport{Report: report}
+		trf := OwnershipTransferReport{Report: report}
 
 		infoContainer := container.New(layout.NewCustomPaddedVBoxLayout(10),
 			reportsLabel,
 			widget.NewButton("Inventory List", func() { getReport(inv) }),
 			widget.NewButton("Transactions Report", func() { getReport(trx) }),
 			widget.NewButton("Balance Report", func() { getReport(blc) }),
+			widget.NewButton("Monthly Statement", func() { getReport(stm) }),
+			widget.NewButton("Valuation by Owner", func() { getReport(val) }),
+			widget.NewButton("Aging Report", func() { getReport(agn) }),
+			widget.NewButton("Usage Forecast", func() { getReport(fcs) }),
+			widget.NewButton("Reservations", func() { getReport(res) }),
+			widget.NewButton("Job Costing", func() { getReport(job) }),
+			widget.NewButton("Storage Billing", func() { getReport(bil) }),
+			widget.NewButton("Ownership Transfers", func() { getReport(trf) }),
+			widget.NewSeparator(),
+			widget.NewButton("Saved Reports", func() { showSavedReports(myApp, db, scheduler) }),
 		)
 
+		dashboard, dashboardContent := newDashboard(db, DashboardActions{
+			showValuation:    func() { getReport(val) },
+			showInventory:    func() { getReport(inv) },
+			showIncoming:     func() { acceptIncomingMaterials(myApp, db) },
+			showTransactions: func() { getReport(trx) },
+			showForecast:     func() { getReport(fcs) },
+		})
+		dashboard.startAutoRefresh()
+
 		actionsContainer := container.New(layout.NewGridLayoutWithColumns(3),
 			customerContainer,
 			materialContainer,
@@ -93,11 +142,57 @@ func main() {
 
 		content := container.New(layout.NewVBoxLayout(),
 			mainLabel,
+			dashboardContent,
+			widget.NewSeparator(),
 			actionsContainer,
 		)
 
 		myWindow.SetContent(content)
-		myWindow.Resize(fyne.NewSize(800, 600))
+		myWindow.Resize(fyne.NewSize(1100, 900))
 		myWindow.ShowAndRun()
 	}
 }
+
+// Generate one saved report, or run the report schedules until the process is stopped
+func runHeadless(reportName string) {
+	db, err := connectToDB()
+	if err != nil {
+		log.Fatalln("Database error: ", err)
+	}
+	defer db.Close()
+
+	if reportName != "" {
+		definitions, err := fetchReportDefinitions(db)
+		if err != nil {
+			log.Fatalln("Error loading saved reports: ", err)
+		}
+
+		for _, definition := range definitions {
+			if definition.name == reportName {
+				files, err := runReportDefinition(db, definition, time.Now())
+				if err != nil {
+					log.Fatalln("Error running \""+reportName+"\": ", err)
+				}
+				for _, file := range files {
+					log.Println("Saved", file)
+				}
+				return
+			}
+		}
+
+		log.Fatalln("Saved report not found: " + reportName)
+	}
+
+	scheduler := newReportScheduler(db)
+	if err := scheduler.reload(); err != nil {
+		log.Fatalln("Error loading report schedules: ", err)
+	}
+	log.Println("Report scheduler started, reports are saved to " + getReportsDir())
+	startInventorySnapshots(db)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	scheduler.stop()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/leekchan/accounting"
+	"github.com/shopspring/decimal"
+)
+
+// Decimals of the unit costs unless UNIT_COST_PRECISION is set
+const defaultUnitCostPrecision = 4
+
+// Costs and values are exact decimals, they are rounded half away from zero
+// to the cents or to the unit cost precision only when they are shown
+var unitCostPrecision = getUnitCostPrecision()
+
+// Decimals of the unit costs, UNIT_COST_PRECISION from 2 to 6
+func getUnitCostPrecision() int32 {
+	if precision, err := strconv.Atoi(os.Getenv("UNIT_COST_PRECISION")); err == nil &&
+		precision >= 2 && precision <= 6 {
+		return int32(precision)
+	}
+
+	return defaultUnitCostPrecision
+}
+
+// Positive unit cost of a text like "0.0137" or "$1,250.50" with no more
+// decimals than the unit cost precision
+func parseUnitCost(text string) (decimal.Decimal, error) {
+	text = strings.NewReplacer(",", "", "$", "").Replace(strings.TrimSpace(text))
+
+	cost, err := decimal.NewFromString(text)
+	if err != nil || !cost.IsPositive() {
+		return decimal.Zero, errors.New("The unit cost must be a positive number, not \"" + text + "\"")
+	}
+	if !cost.Equal(cost.Truncate(unitCostPrecision)) {
+		return decimal.Zero, errors.New("The unit cost can have up to " +
+			strconv.Itoa(int(unitCostPrecision)) + " decimals")
+	}
+
+	return cost, nil
+}
+
+// Money value rounded to the cents, e.g. "$1,250.50"
+func formatMoney(value decimal.Decimal) string {
+	return accLib.FormatMoneyDecimal(value)
+}
+
+// Unit cost rounded to the unit cost precision, e.g. "$0.0137"
+func formatUnitCost(cost decimal.Decimal) string {
+	unitCostLib := accounting.Accounting{Symbol: "$", Precision: int(unitCostPrecision)}
+
+	return unitCostLib.FormatMoneyDecimal(cost)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseUnitCost(t *testing.T) {
+	defer func(precision int32) { unitCostPrecision = precision }(unitCostPrecision)
+
+	tests := []struct {
+		precision int32
+		text      string
+		want      string
+		wantErr   bool
+	}{
+		{4, "0.0137", "0.0137", false},
+		{4, "$1,250.50", "1250.5", false},
+		{4, " 12 ", "12", false},
+		{4, "0.00137", "", true},
+		{4, "0.013700", "0.0137", false},
+		{6, "0.00137", "0.00137", false},
+		{6, "0.000001", "0.000001", false},
+		{6, "0.0000001", "", true},
+		{2, "0.0137", "", true},
+		{4, "0", "", true},
+		{4, "-1.25", "", true},
+		{4, "abc", "", true},
+		{4, "", "", true},
+	}
+
+	for _, tt := range tests {
+		unitCostPrecision = tt.precision
+
+		cost, err := parseUnitCost(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseUnitCost(%q) at precision %d error = %v, want error %v",
+				tt.text, tt.precision, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !cost.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("parseUnitCost(%q) at precision %d = %s, want %s", tt.text, tt.precision, cost, tt.want)
+		}
+	}
+}
+
+func TestFormatUnitCost(t *testing.T) {
+	defer func(precision int32) { unitCostPrecision = precision }(unitCostPrecision)
+
+	tests := []struct {
+		precision int32
+		cost      string
+		want      string
+	}{
+		{4, "0.0137", "$0.0137"},
+		{4, "1250.5", "$1,250.5000"},
+		{4, "0.01375", "$0.0138"},
+		{6, "0.0137", "$0.013700"},
+		{6, "0.0000015", "$0.000002"},
+		{2, "0.0137", "$0.01"},
+	}
+
+	for _, tt := range tests {
+		unitCostPrecision = tt.precision
+
+		if got := formatUnitCost(decimal.RequireFromString(tt.cost)); got != tt.want {
+			t.Errorf("formatUnitCost(%s) at precision %d = %q, want %q", tt.cost, tt.precision, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel of the notifications sent by the triggers in sql/create_db.sql
+const changesChannel = "inventory_changes"
+
+const (
+	materialsTable         = "materials"
+	incomingMaterialsTable = "incoming_materials"
+	transactionsTable      = "transactions_log"
+	reservationsTable      = "reservations"
+)
+
+// A burst of changes, e.g. a move, refreshes a window once
+const changesDebounce = 300 * time.Millisecond
+
+// Notifier of the open windows, nil when the listener is not connected
+var changes *ChangeNotifier
+
+type changeSubscriber struct {
+	tables   []string
+	onChange func()
+	timer    *time.Timer
+}
+
+// Listens to the table changes of all workstations and calls the
+// subscribers of the changed tables
+type ChangeNotifier struct {
+	listener    *pq.Listener
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]*changeSubscriber
+	due         chan int // the subscribers to call, one at a time
+}
+
+func startChangeNotifier() (*ChangeNotifier, error) {
+	listener := pq.NewListener(getConnectionInfo(), 10*time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Println("Error change listener: ", err)
+			}
+		})
+
+	if err := listener.Listen(changesChannel); err != nil {
+		listener.Close()
+		log.Println("Error startChangeNotifier: ", err)
+		return nil, err
+	}
+
+	n := &ChangeNotifier{
+		listener:    listener,
+		subscribers: make(map[int]*changeSubscriber),
+		due:         make(chan int, 16),
+	}
+	go n.run()
+	go n.dispatch()
+
+	return n, nil
+}
+
+func (n *ChangeNotifier) run() {
+	for {
+		select {
+		case notification, ok := <-n.listener.Notify:
+			if !ok {
+				return
+			}
+			// The connection has been restored and changes may have been missed
+			if notification == nil {
+				n.notify("")
+				continue
+			}
+			n.notify(notification.Extra)
+		case <-time.After(90 * time.Second):
+			go n.listener.Ping()
+		}
+	}
+}
+
+// Call the subscribers of the table, or all of them when the table is empty
+func (n *ChangeNotifier) notify(table string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for id, subscriber := range n.subscribers {
+		if table != "" && !slices.Contains(subscriber.tables, table) {
+			continue
+		}
+		if subscriber.timer != nil {
+			continue
+		}
+
+		subscriberID := id
+		subscriber.timer = time.AfterFunc(changesDebounce, func() {
+			n.due <- subscriberID
+		})
+	}
+}
+
+// Call the due subscribers one after another on a single goroutine,
+// so the windows never reload at the same time
+func (n *ChangeNotifier) dispatch() {
+	for id := range n.due {
+		n.mu.Lock()
+		subscriber, ok := n.subscribers[id]
+		if ok {
+			subscriber.timer = nil
+		}
+		n.mu.Unlock()
+
+		// The window may have been closed in the meantime
+		if ok {
+			subscriber.onChange()
+		}
+	}
+}
+
+// Call onChange after the tables are changed until the returned function is called.
+// onChange runs on the goroutine of the notifier, not on the one of the UI.
+func (n *ChangeNotifier) subscribe(onChange func(), tables ...string) func() {
+	if n == nil {
+		return func() {}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.nextID++
+	id := n.nextID
+	n.subscribers[id] = &changeSubscriber{tables: tables, onChange: onChange}
+
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		if subscriber, ok := n.subscribers[id]; ok {
+			if subscriber.timer != nil {
+				subscriber.timer.Stop()
+			}
+			delete(n.subscribers, id)
+		}
+	}
+}
+
+func (n *ChangeNotifier) close() {
+	if n == nil {
+		return
+	}
+
+	n.listener.Close()
+}
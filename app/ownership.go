@@ -0,0 +1,319 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+// Sale of stock between the customer and Tag
+type OwnershipTransfer struct {
+	id            int
+	customerName  string
+	stockID       string
+	locationName  string
+	fromOwner     string
+	toOwner       string
+	quantity      int
+	transferPrice decimal.Decimal // per unit
+	notes         string
+	createdAt     time.Time
+}
+
+// Transfer documents of a customer, a single transfer when transferID is set
+type OwnershipTransferReport struct {
+	Report
+	trfFilter  SearchFilter
+	transferID int
+}
+
+func getOtherOwner(owner string) string {
+	if owner == "Tag" {
+		return "Customer"
+	}
+
+	return "Tag"
+}
+
+// Amount the customer owes for the transfer, negative when Tag buys the stock
+func (t OwnershipTransfer) getAmountDue() decimal.Decimal {
+	amount := t.transferPrice.Mul(decimal.New(int64(t.quantity), 0))
+	if t.toOwner == "Tag" {
+		return amount.Neg()
+	}
+
+	return amount
+}
+
+// Sell a quantity of a material to the other owner at a transfer price per unit.
+// The quantity leaves the cost layers of the previous owner and makes a new cost
+// layer at the transfer price on the row of the new owner in the same location.
+// The ID of the transfer is returned.
+func transferOwnership(db *sql.DB, materialID int, quantity int, price decimal.Decimal, notes string) (int, error) {
+	if quantity <= 0 {
+		return 0, errors.New("The quantity must be a positive number")
+	}
+	if price.IsNegative() {
+		return 0, errors.New("The transfer price cannot be negative")
+	}
+
+	var transferID int
+
+	err := withTransaction(db, func(tx *sql.Tx) error {
+		var currMaterial MaterialInfo
+		if err := tx.QueryRow(`
+			SELECT material_id, item_id, stock_id, COALESCE(location_id, 0), customer_id, quantity, owner
+			FROM materials
+			WHERE material_id = $1
+			FOR UPDATE;`, materialID).Scan(
+			&currMaterial.materialId,
+			&currMaterial.itemId,
+			&currMaterial.stockId,
+			&currMaterial.locationId,
+			&currMaterial.customerId,
+			&currMaterial.quantity,
+			&currMaterial.owner,
+		); err != nil {
+			log.Println("Error transferOwnership1: ", err)
+			return err
+		}
+
+		if currMaterial.quantity < quantity {
+			return errors.New(`The transferred quantity (` + strconv.Itoa(quantity) +
+				`) is more than the actual one (` + strconv.Itoa(currMaterial.quantity) + `)`)
+		}
+		newOwner := getOtherOwner(currMaterial.owner)
+
+		if _, err := tx.Exec(`UPDATE materials SET quantity = (quantity - $1) WHERE material_id = $2;`,
+			quantity, materialID); err != nil {
+			log.Println("Error transferOwnership2: ", err)
+			return err
+		}
+
+		// The row of the new owner in the same location
+		var newMaterialID int
+		err := tx.QueryRow(`
+			UPDATE materials
+			SET quantity = (quantity + $1)
+			WHERE
+				item_id = $2 AND
+				location_id IS NOT DISTINCT FROM NULLIF($3, 0) AND
+				owner = $4
+			RETURNING material_id;`,
+			quantity, currMaterial.itemId, currMaterial.locationId, newOwner,
+		).Scan(&newMaterialID)
+		if err != nil && err != sql.ErrNoRows {
+			log.Println("Error transferOwnership3: ", err)
+			return err
+		}
+
+		if newMaterialID == 0 {
+			err := tx.QueryRow(`
+				INSERT INTO materials
+					(item_id, stock_id, location_id, customer_id, notes, quantity, updated_at, cost, owner)
+				VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9)
+				RETURNING material_id;`,
+				currMaterial.itemId, currMaterial.stockId, currMaterial.locationId, currMaterial.customerId,
+				notes, quantity, time.Now(), price, newOwner,
+			).Scan(&newMaterialID)
+			if err != nil {
+				log.Println("Error transferOwnership4: ", err)
+				return err
+			}
+		}
+
+		// The previous owner gives up the oldest cost layers at their book value
+		updatedAt := time.Now()
+		bookValue, err := addTranscation(&TransactionInfo{
+			materialId: currMaterial.materialId,
+			stockId:    currMaterial.stockId,
+			quantity:   -quantity,
+			notes:      notes,
+			updatedAt:  updatedAt,
+			trxType:    ownershipTrx,
+		}, tx)
+		if err != nil {
+			log.Println("Error transferOwnership5: ", err)
+			return errors.New("Updating transactions error: " + err.Error())
+		}
+
+		// The new owner gets a layer at the transfer price
+		if _, err := addTranscation(&TransactionInfo{
+			materialId: newMaterialID,
+			stockId:    currMaterial.stockId,
+			quantity:   quantity,
+			notes:      notes,
+			cost:       price,
+			updatedAt:  updatedAt,
+			trxType:    ownershipTrx,
+		}, tx); err != nil {
+			log.Println("Error transferOwnership6: ", err)
+			return errors.New("Updating transactions error: " + err.Error())
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO ownership_transfers
+				(customer_id, item_id, stock_id, location_id, from_material_id, to_material_id,
+				from_owner, to_owner, quantity, transfer_price, book_value, notes, created_at)
+			VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING transfer_id;`,
+			currMaterial.customerId, currMaterial.itemId, currMaterial.stockId, currMaterial.locationId,
+			currMaterial.materialId, newMaterialID, currMaterial.owner, newOwner, quantity, price,
+			bookValue, notes, updatedAt,
+		).Scan(&transferID)
+		if err != nil {
+			log.Println("Error transferOwnership7: ", err)
+			return err
+		}
+
+		return nil
+	})
+
+	return transferID, err
+}
+
+// Transfers of a customer in the period, a single transfer when transferID is set
+func fetchOwnershipTransfers(db *sql.DB, filter SearchFilter, transferID int) ([]OwnershipTransfer, error) {
+	rows, err := db.Query(`
+		SELECT t.transfer_id, c.name, t.stock_id, COALESCE(l.name, ''), t.from_owner, t.to_owner,
+			t.quantity, t.transfer_price, COALESCE(t.notes, ''), t.created_at
+		FROM ownership_transfers t
+		JOIN customers c ON c.customer_id = t.customer_id
+		LEFT JOIN locations l ON l.location_id = t.location_id
+		WHERE
+			($1 = 0 OR t.transfer_id = $1) AND
+			($2 = 0 OR t.customer_id = $2) AND
+			($3::timestamp IS NULL OR t.created_at >= $3) AND
+			($4::timestamp IS NULL OR t.created_at < $4)
+		ORDER BY t.transfer_id;`,
+		transferID, filter.customerID, toNullTime(filter.dateFrom), toNullTime(getDayAfter(filter.dateTo)))
+	if err != nil {
+		log.Println("Error fetchOwnershipTransfers1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []OwnershipTransfer
+
+	for rows.Next() {
+		var t OwnershipTransfer
+		if err := rows.Scan(&t.id, &t.customerName, &t.stockID, &t.locationName, &t.fromOwner, &t.toOwner,
+			&t.quantity, &t.transferPrice, &t.notes, &t.createdAt); err != nil {
+			log.Println("Error fetchOwnershipTransfers2: ", err)
+			return transfers, err
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (r OwnershipTransferReport) getReportList() [][]string {
+	trfList := [][]string{
+		{
+			"Transfer No.", "Date", "Customer", "Stock ID", "Location", "From", "To",
+			"Quantity", "Transfer Price, USD", "Amount Due, USD", "Notes",
+		},
+	}
+
+	transfers, err := fetchOwnershipTransfers(r.db, r.trfFilter, r.transferID)
+	if err != nil {
+		return trfList
+	}
+
+	for _, t := range transfers {
+		trfList = append(trfList, []string{
+			strconv.Itoa(t.id),
+			t.createdAt.Format("1/2/2006"),
+			t.customerName,
+			t.stockID,
+			t.locationName,
+			t.fromOwner,
+			t.toOwner,
+			strconv.Itoa(t.quantity),
+			formatUnitCost(t.transferPrice),
+			formatMoney(t.getAmountDue()),
+			t.notes,
+		})
+	}
+
+	return trfList
+}
+
+// The amounts due are negative for the stock bought by Tag
+func (r OwnershipTransferReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		NumberColumn, DateColumn, TextColumn, TextColumn, TextColumn, TextColumn, TextColumn,
+		QuantityColumn, PriceColumn, ValueColumn, TextColumn,
+	}
+}
+
+func (r OwnershipTransferReport) getReportHeader() ReportHeader {
+	if r.transferID != 0 {
+		return ReportHeader{
+			title:        "Ownership Transfer No. " + strconv.Itoa(r.transferID),
+			customerName: r.trfFilter.customerName,
+			period:       formatReportDate(time.Now()),
+		}
+	}
+
+	return ReportHeader{
+		title:        "Ownership Transfers",
+		customerName: r.trfFilter.customerName,
+		period:       formatPeriod(r.trfFilter.dateFrom, r.trfFilter.dateTo),
+	}
+}
+
+func (r OwnershipTransferReport) showReport() {
+	customers, _ := fetchCustomers(r.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	dateFromEntry := newDateEntry(r.window)
+	dateToEntry := newDateEntry(r.window)
+	rangeSelector := newQuickRangeSelector(dateFromEntry, dateToEntry)
+
+	dialog := dialog.NewForm("Filter Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer", customerSelector),
+			widget.NewFormItem("Period", rangeSelector),
+			widget.NewFormItem("Date From", dateFromEntry),
+			widget.NewFormItem("Date To", dateToEntry),
+		}, func(confirm bool) {
+			if confirm {
+				from, to, err := getDateRange(dateFromEntry, dateToEntry)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), r.window)
+					return
+				}
+
+				r.trfFilter = SearchFilter{
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+					dateFrom:     from,
+					dateTo:       to,
+				}
+
+				trfList := r.getReportList()
+				showReportWindow(r.app, "Ownership Transfers", r, trfList,
+					"ownership_transfers_"+safeFileName(customerSelector.Selected)+"_"+time.Now().Format("2006-01-02"),
+					fyne.NewSize(1200, 600))
+			}
+		}, r.window)
+
+	dialog.Resize(fyne.NewSize(600, 300))
+	dialog.Show()
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/leekchan/accounting"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	companyName     = "Tag Systems USA"
+	companyLogoPath = "./assets/logo.png"
+)
+
+const (
+	pdfMargin    = 10.0
+	pdfRowHeight = 6.0
+)
+
+type ReportHeader struct {
+	title        string
+	customerName string
+	period       string
+}
+
+// Write the report as a paginated PDF document with the company header,
+// the customer and period block, repeated table headers on every page,
+// page and grand totals and a signature line
+func writePDF(w io.Writer, list [][]string, columnTypes []ColumnType, header ReportHeader) error {
+	if len(list) == 0 {
+		return errors.New("the report is empty")
+	}
+
+	orientation := "P"
+	if len(list[0]) > 6 {
+		orientation = "L"
+	}
+
+	pdf := gofpdf.New(orientation, "mm", "Letter", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.AliasNbPages("")
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	printedAt := time.Now().Format("01/02/2006 03:04 PM")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin - 2)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.CellFormat(0, 5, tr("Printed "+printedAt), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	widths := getPDFColumnWidths(pdf, list, pageWidth-2*pdfMargin)
+	hasTotals := false
+	for c := range list[0] {
+		colType := getColumnType(columnTypes, c)
+		if colType == QuantityColumn || colType == ValueColumn {
+			hasTotals = true
+		}
+	}
+
+	// Leave room for the page total and the footer
+	bottom := pageHeight - pdfMargin - 2*pdfRowHeight - 5
+
+	newPage := func() {
+		pdf.AddPage()
+		drawPDFHeader(pdf, tr, header, pageWidth)
+		drawPDFRow(pdf, tr, list[0], widths, columnTypes, true)
+	}
+
+	pageTotals := make([]decimal.Decimal, len(list[0]))
+	grandTotals := make([]decimal.Decimal, len(list[0]))
+
+	newPage()
+	for _, row := range list[1:] {
+		if pdf.GetY()+pdfRowHeight > bottom {
+			if hasTotals {
+				drawPDFTotals(pdf, tr, "Page Total", pageTotals, widths, columnTypes)
+			}
+			pageTotals = make([]decimal.Decimal, len(list[0]))
+			newPage()
+		}
+
+		drawPDFRow(pdf, tr, row, widths, columnTypes, false)
+		addPDFTotals(row, columnTypes, pageTotals, grandTotals)
+	}
+
+	if hasTotals {
+		drawPDFTotals(pdf, tr, "Page Total", pageTotals, widths, columnTypes)
+		drawPDFTotals(pdf, tr, "Grand Total", grandTotals, widths, columnTypes)
+	}
+
+	// Signature block
+	if pdf.GetY()+30 > pageHeight-pdfMargin-5 {
+		pdf.AddPage()
+		drawPDFHeader(pdf, tr, header, pageWidth)
+	}
+	drawPDFSignature(pdf, tr, pageWidth)
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+
+	return pdf.Output(w)
+}
+
+func drawPDFHeader(pdf *gofpdf.Fpdf, tr func(string) string, header ReportHeader, pageWidth float64) {
+	textX := pdfMargin
+
+	if _, err := os.Stat(companyLogoPath); err == nil {
+		pdf.ImageOptions(companyLogoPath, pdfMargin, pdfMargin, 0, 14, false,
+			gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+		textX += 40
+	}
+
+	pdf.SetXY(textX, pdfMargin)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 7, tr(companyName), "", 2, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.CellFormat(0, 7, tr(header.title), "", 1, "L", false, 0, "")
+
+	pdf.SetY(pdfMargin + 16)
+	pdf.SetFont("Arial", "", 9)
+
+	customerName := header.customerName
+	if customerName == "" {
+		customerName = "All customers"
+	}
+	pdf.CellFormat(20, 5, "Customer:", "", 0, "L", false, 0, "")
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(0, 5, tr(customerName), "", 1, "L", false, 0, "")
+
+	if header.period != "" {
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(20, 5, "Period:", "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "B", 9)
+		pdf.CellFormat(0, 5, tr(header.period), "", 1, "L", false, 0, "")
+	}
+
+	y := pdf.GetY() + 2
+	pdf.SetDrawColor(79, 129, 189)
+	pdf.Line(pdfMargin, y, pageWidth-pdfMargin, y)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetY(y + 3)
+}
+
+func drawPDFRow(pdf *gofpdf.Fpdf, tr func(string) string, row []string, widths []float64, columnTypes []ColumnType, isHeader bool) {
+	if isHeader {
+		pdf.SetFont("Arial", "B", 8)
+		pdf.SetFillColor(79, 129, 189)
+		pdf.SetTextColor(255, 255, 255)
+	} else {
+		pdf.SetFont("Arial", "", 8)
+	}
+
+	for c, value := range row {
+		align := "L"
+		if !isHeader && isNumericColumn(getColumnType(columnTypes, c)) {
+			align = "R"
+		}
+
+		pdf.CellFormat(widths[c], pdfRowHeight, fitPDFText(pdf, tr(value), widths[c]),
+			"1", 0, align, isHeader, 0, "")
+	}
+	pdf.Ln(-1)
+
+	if isHeader {
+		pdf.SetTextColor(0, 0, 0)
+	}
+}
+
+func drawPDFTotals(pdf *gofpdf.Fpdf, tr func(string) string, label string, totals []decimal.Decimal, widths []float64, columnTypes []ColumnType) {
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(230, 230, 230)
+
+	for c := range totals {
+		value := ""
+		align := "R"
+
+		switch getColumnType(columnTypes, c) {
+		case QuantityColumn:
+			value = accounting.FormatNumberDecimal(totals[c], 0, ",", ".")
+		case ValueColumn:
+			value = formatMoney(totals[c])
+		default:
+			if c == 0 {
+				value = label
+				align = "L"
+			}
+		}
+
+		pdf.CellFormat(widths[c], pdfRowHeight, tr(value), "1", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func drawPDFSignature(pdf *gofpdf.Fpdf, tr func(string) string, pageWidth float64) {
+	lineWidth := (pageWidth - 2*pdfMargin - 20) / 2
+
+	pdf.Ln(18)
+	y := pdf.GetY()
+	pdf.Line(pdfMargin, y, pdfMargin+lineWidth, y)
+	pdf.Line(pageWidth-pdfMargin-lineWidth, y, pageWidth-pdfMargin, y)
+
+	pdf.SetFont("Arial", "", 8)
+	pdf.CellFormat(lineWidth, 5, tr("Authorized signature, "+companyName), "", 0, "L", false, 0, "")
+	pdf.SetX(pageWidth - pdfMargin - lineWidth)
+	pdf.CellFormat(lineWidth, 5, "Date", "", 1, "L", false, 0, "")
+}
+
+func addPDFTotals(row []string, columnTypes []ColumnType, totals ...[]decimal.Decimal) {
+	for c, value := range row {
+		var number decimal.Decimal
+		var err error
+
+		switch getColumnType(columnTypes, c) {
+		case QuantityColumn, ValueColumn:
+			number, err = parseMoney(value)
+		default:
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		for _, t := range totals {
+			t[c] = t[c].Add(number)
+		}
+	}
+}
+
+// Split the page width between columns proportionally to their content
+func getPDFColumnWidths(pdf *gofpdf.Fpdf, list [][]string, pageWidth float64) []float64 {
+	pdf.SetFont("Arial", "", 8)
+
+	widths := make([]float64, len(list[0]))
+	var total float64
+	for c := range list[0] {
+		for _, row := range list {
+			if c < len(row) {
+				widths[c] = max(widths[c], pdf.GetStringWidth(row[c])+3)
+			}
+		}
+		widths[c] = min(max(widths[c], 12), 60)
+		total += widths[c]
+	}
+
+	for c := range widths {
+		widths[c] = widths[c] * pageWidth / total
+	}
+
+	return widths
+}
+
+// Cut the text so that it fits into the cell
+func fitPDFText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text)+2 <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...")+2 > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "..."
+}
+
+func isNumericColumn(colType ColumnType) bool {
+	return colType == NumberColumn || colType == QuantityColumn ||
+		colType == PriceColumn || colType == AmountColumn || colType == ValueColumn
+}
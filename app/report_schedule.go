@@ -0,0 +1,404 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	inventoryReportType   = "Inventory List"
+	transactionReportType = "Transactions Report"
+	balanceReportType     = "Balance Report"
+	statementReportType   = "Monthly Statement"
+	valuationReportType   = "Valuation by Owner"
+	agingReportType       = "Aging Report"
+	forecastReportType    = "Usage Forecast"
+	reservationReportType = "Reservations"
+	jobCostReportType     = "Job Costing"
+	billingReportType     = "Storage Billing"
+	ownershipReportType   = "Ownership Transfers"
+)
+
+var reportTypes = []string{
+	inventoryReportType, transactionReportType, balanceReportType, statementReportType,
+	valuationReportType, agingReportType, forecastReportType, reservationReportType,
+	jobCostReportType, billingReportType, ownershipReportType,
+}
+
+// Folder of the generated reports, REPORTS_DIR or ./reports
+func getReportsDir() string {
+	if dir := os.Getenv("REPORTS_DIR"); dir != "" {
+		return dir
+	}
+
+	return "./reports"
+}
+
+// Named report with its filter, a date range relative to the run date
+// and an optional cron schedule
+type ReportDefinition struct {
+	id           int
+	name         string
+	reportType   string
+	stockID      string
+	customerID   int
+	customerName string
+	locationID   int
+	materialType string
+	owner        string
+	dateRange    string // one of the quick ranges, empty for all dates
+	format       string
+	schedule     string // cron spec, empty to run on demand
+	email        string // comma separated recipients
+	lastRunAt    sql.NullTime
+}
+
+func fetchReportDefinitions(db *sql.DB) ([]ReportDefinition, error) {
+	rows, err := db.Query(`
+	SELECT rd.definition_id, rd.name, rd.report_type,
+		   COALESCE(rd.stock_id, ''), COALESCE(rd.customer_id, 0), COALESCE(c.name, ''),
+		   COALESCE(rd.location_id, 0), COALESCE(rd.material_type, ''), COALESCE(rd.owner, ''),
+		   COALESCE(rd.date_range, ''), rd.output_format, COALESCE(rd.schedule, ''),
+		   COALESCE(rd.email, ''), rd.last_run_at
+	FROM report_definitions rd
+	LEFT JOIN customers c ON c.customer_id = rd.customer_id
+	ORDER BY rd.name;`)
+	if err != nil {
+		log.Println("Error fetchReportDefinitions1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var definitions []ReportDefinition
+
+	for rows.Next() {
+		var d ReportDefinition
+		if err := rows.Scan(&d.id, &d.name, &d.reportType,
+			&d.stockID, &d.customerID, &d.customerName,
+			&d.locationID, &d.materialType, &d.owner,
+			&d.dateRange, &d.format, &d.schedule,
+			&d.email, &d.lastRunAt); err != nil {
+			log.Println("Error fetchReportDefinitions2: ", err)
+			return definitions, err
+		}
+		definitions = append(definitions, d)
+	}
+	if err = rows.Err(); err != nil {
+		return definitions, err
+	}
+
+	return definitions, nil
+}
+
+// Insert a new definition or update the existing one
+func saveReportDefinition(db *sql.DB, d ReportDefinition) error {
+	args := []interface{}{
+		d.name, d.reportType, nullString(d.stockID), nullInt(d.customerID), nullInt(d.locationID),
+		nullString(d.materialType), nullString(d.owner), nullString(d.dateRange), d.format,
+		nullString(d.schedule), nullString(d.email),
+	}
+
+	var err error
+	if d.id == 0 {
+		_, err = db.Exec(`INSERT INTO report_definitions (name, report_type, stock_id, customer_id,
+							location_id, material_type, owner, date_range, output_format, schedule, email)
+						  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`, args...)
+	} else {
+		_, err = db.Exec(`UPDATE report_definitions SET name = $1, report_type = $2, stock_id = $3,
+							customer_id = $4, location_id = $5, material_type = $6, owner = $7,
+							date_range = $8, output_format = $9, schedule = $10, email = $11
+						  WHERE definition_id = $12;`, append(args, d.id)...)
+	}
+	if err != nil {
+		log.Println("Error saveReportDefinition: ", err)
+	}
+
+	return err
+}
+
+func deleteReportDefinition(db *sql.DB, id int) error {
+	if _, err := db.Exec("DELETE FROM report_definitions WHERE definition_id = $1;", id); err != nil {
+		log.Println("Error deleteReportDefinition: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullInt(value int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(value), Valid: value != 0}
+}
+
+// The filter of the definition with the dates of its range on the run date.
+// The reports as of a date use the end of the range.
+func (d ReportDefinition) getFilter(now time.Time) SearchFilter {
+	filter := SearchFilter{
+		stockID:      d.stockID,
+		customerID:   d.customerID,
+		customerName: d.customerName,
+		locationID:   d.locationID,
+		materialType: d.materialType,
+		owner:        d.owner,
+	}
+
+	if d.dateRange != "" {
+		filter.dateFrom, filter.dateTo = getQuickRange(d.dateRange, now)
+		filter.dateAsOf = filter.dateTo
+	} else {
+		filter.dateAsOf = now
+	}
+
+	return filter
+}
+
+func (d ReportDefinition) getReporter(db *sql.DB, now time.Time) (Reporter, error) {
+	report := Report{db: db}
+	filter := d.getFilter(now)
+
+	switch d.reportType {
+	case inventoryReportType:
+		return InventoryReport{Report: report, invFilter: filter}, nil
+	case transactionReportType:
+		return TransactionReport{Report: report, trxFilter: filter}, nil
+	case balanceReportType:
+		return BalanceReport{Report: report, blcFilter: filter}, nil
+	case statementReportType:
+		return StatementReport{Report: report, stmFilter: filter}, nil
+	case valuationReportType:
+		return ValuationReport{Report: report, valFilter: filter}, nil
+	case agingReportType:
+		return AgingReport{Report: report, agnFilter: filter}, nil
+	case forecastReportType:
+		return ForecastReport{Report: report, fcsFilter: filter}, nil
+	case reservationReportType:
+		return ReservationReport{Report: report, resFilter: filter}, nil
+	case jobCostReportType:
+		return JobCostReport{Report: report, jobFilter: filter}, nil
+	case billingReportType:
+		return StorageBillingReport{Report: report, bilFilter: filter}, nil
+	case ownershipReportType:
+		return OwnershipTransferReport{Report: report, trfFilter: filter}, nil
+	default:
+		return nil, errors.New("unknown report type: " + d.reportType)
+	}
+}
+
+func (d ReportDefinition) validate() error {
+	switch {
+	case strings.TrimSpace(d.name) == "":
+		return errors.New("the name is required")
+	case d.reportType == "":
+		return errors.New("the report type is required")
+	case d.format == "":
+		return errors.New("the output format is required")
+	case d.dateRange == "" && (d.reportType == transactionReportType || d.reportType == statementReportType ||
+		d.reportType == billingReportType):
+		return errors.New(d.reportType + " needs a date range")
+	}
+
+	if d.schedule != "" {
+		if _, err := cron.ParseStandard(d.schedule); err != nil {
+			return errors.New("invalid schedule: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Generate the report files of a definition in the reports folder
+// and email them when there are recipients. A statement or a billing statement
+// without a customer makes a file for every customer with entries in the period.
+func runReportDefinition(db *sql.DB, d ReportDefinition, now time.Time) ([]string, error) {
+	var files []string
+
+	reporters := []Reporter{}
+	names := []string{}
+
+	if (d.reportType == statementReportType || d.reportType == billingReportType) && d.customerID == 0 {
+		customers, err := fetchCustomers(db)
+		if err != nil {
+			return files, err
+		}
+		for _, customer := range customers {
+			customerDef := d
+			customerDef.customerID = customer.id
+			customerDef.customerName = customer.name
+			r, _ := customerDef.getReporter(db, now)
+			reporters = append(reporters, r)
+			names = append(names, d.name+"_"+customer.name)
+		}
+	} else {
+		r, err := d.getReporter(db, now)
+		if err != nil {
+			return files, err
+		}
+		reporters = append(reporters, r)
+		names = append(names, d.name)
+	}
+
+	dir := getReportsDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Println("Error runReportDefinition1: ", err)
+		return files, err
+	}
+
+	for i, r := range reporters {
+		list := r.getReportList()
+		if len(list) == 1 && len(reporters) > 1 {
+			continue
+		}
+
+		path := filepath.Join(dir, safeFileName(names[i])+"_"+now.Format("2006-01-02_1504")+"."+d.format)
+		if err := writeReportFile(path, r, list, d.format); err != nil {
+			log.Println("Error runReportDefinition2: ", err)
+			return files, err
+		}
+		files = append(files, path)
+	}
+
+	if d.email != "" && len(files) > 0 {
+		if err := sendReportEmail(strings.Split(d.email, ","), d.name, files); err != nil {
+			log.Println("Error runReportDefinition3: ", err)
+			return files, err
+		}
+	}
+
+	if _, err := db.Exec("UPDATE report_definitions SET last_run_at = $1 WHERE definition_id = $2;",
+		now, d.id); err != nil {
+		log.Println("Error runReportDefinition4: ", err)
+	}
+
+	return files, nil
+}
+
+func writeReportFile(path string, r Reporter, list [][]string, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeReport(file, r, list, format); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Run a scheduled definition once for all the workstations: the run is skipped
+// while another one holds the lock of the definition or when it has already
+// run since the scheduled time
+func runScheduledReport(db *sql.DB, d ReportDefinition, now time.Time) ([]string, error) {
+	var files []string
+
+	err := withTransaction(db, func(tx *sql.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock(hashtext('report_definitions'), $1);`,
+			d.id).Scan(&locked); err != nil {
+			log.Println("Error runScheduledReport1: ", err)
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		// The schedules run on the minute
+		var done bool
+		if err := tx.QueryRow(`SELECT COALESCE(last_run_at >= $2::timestamp, FALSE)
+							   FROM report_definitions WHERE definition_id = $1;`,
+			d.id, now.Truncate(time.Minute)).Scan(&done); err != nil {
+			log.Println("Error runScheduledReport2: ", err)
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		var err error
+		files, err = runReportDefinition(db, d, now)
+		return err
+	})
+
+	return files, err
+}
+
+// Runs the scheduled definitions in the background
+type ReportScheduler struct {
+	db   *sql.DB
+	mu   sync.Mutex
+	cron *cron.Cron
+}
+
+func newReportScheduler(db *sql.DB) *ReportScheduler {
+	return &ReportScheduler{db: db}
+}
+
+// Load the schedules from the database and restart the timers,
+// the nightly inventory snapshot is scheduled as well
+func (s *ReportScheduler) reload() error {
+	definitions, err := fetchReportDefinitions(s.db)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+	s.cron = cron.New()
+
+	for _, definition := range definitions {
+		if definition.schedule == "" {
+			continue
+		}
+
+		d := definition
+		if _, err := s.cron.AddFunc(d.schedule, func() {
+			files, err := runScheduledReport(s.db, d, time.Now())
+			if err != nil {
+				log.Println("Error scheduled report \""+d.name+"\": ", err)
+				return
+			}
+			if len(files) > 0 {
+				log.Println("Scheduled report \""+d.name+"\" saved:", strings.Join(files, ", "))
+			}
+		}); err != nil {
+			log.Println("Error reload schedule \""+d.name+"\": ", err)
+		}
+	}
+
+	if _, err := s.cron.AddFunc(snapshotSchedule, func() {
+		if _, err := updateInventorySnapshots(s.db, time.Now()); err != nil {
+			log.Println("Error scheduled inventory snapshot: ", err)
+		}
+	}); err != nil {
+		log.Println("Error reload inventory snapshots: ", err)
+	}
+
+	s.cron.Start()
+
+	return nil
+}
+
+func (s *ReportScheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cron != nil {
+		<-s.cron.Stop().Done()
+	}
+}
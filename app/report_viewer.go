@@ -0,0 +1,455 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leekchan/accounting"
+	"github.com/shopspring/decimal"
+)
+
+const defaultColumnWidth = 150
+
+// Reusable table of a report with sorting, a live filter,
+// column selection, persisted column widths and a summary row
+type ReportViewer struct {
+	window      fyne.Window
+	reporter    Reporter
+	columnTypes []ColumnType
+	name        string // the file name of the exports
+	prefsName   string // the key of the stored preferences
+
+	// The report is reloaded from the change notifications while
+	// the table reads it, the list and the view are guarded by mu
+	mu          sync.Mutex
+	list        [][]string // the header row and all data rows
+	rows        [][]string // the filtered and sorted data rows
+	visibleCols []int
+	widths      []float64
+	hidden      []bool
+	sortCol     int
+	sortDesc    bool
+	filterText  string
+
+	table  *widget.Table
+	footer *widget.Label
+}
+
+// Only the visible columns of a report are exported
+type visibleColumnsReporter struct {
+	Reporter
+	columnTypes []ColumnType
+}
+
+func (v visibleColumnsReporter) getColumnTypes() []ColumnType {
+	return v.columnTypes
+}
+
+func newReportViewer(window fyne.Window, r Reporter, list [][]string, name string) *ReportViewer {
+	v := &ReportViewer{
+		window:      window,
+		reporter:    r,
+		list:        list,
+		columnTypes: r.getColumnTypes(),
+		name:        name,
+		prefsName:   strings.TrimPrefix(fmt.Sprintf("%T", r), "main."),
+		sortCol:     -1,
+	}
+
+	numCols := len(list[0])
+	prefs := fyne.CurrentApp().Preferences()
+
+	v.widths = prefs.FloatList(v.getPrefsKey("widths"))
+	if len(v.widths) != numCols {
+		v.widths = make([]float64, numCols)
+		for c := range v.widths {
+			v.widths[c] = defaultColumnWidth
+		}
+	}
+
+	v.hidden = prefs.BoolList(v.getPrefsKey("hidden"))
+	if len(v.hidden) != numCols {
+		v.hidden = make([]bool, numCols)
+	}
+
+	v.table = widget.NewTable(
+		func() (int, int) {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+
+			return len(v.rows), len(v.visibleCols)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Transactions")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			v.mu.Lock()
+			if i.Row >= len(v.rows) || i.Col >= len(v.visibleCols) {
+				v.mu.Unlock()
+				return
+			}
+			text := v.rows[i.Row][v.visibleCols[i.Col]]
+			numeric := isNumericColumn(getColumnType(v.columnTypes, v.visibleCols[i.Col]))
+			v.mu.Unlock()
+
+			label := o.(*widget.Label)
+			if numeric {
+				label.Alignment = fyne.TextAlignTrailing
+			} else {
+				label.Alignment = fyne.TextAlignLeading
+			}
+			label.SetText(text)
+		})
+
+	v.table.ShowHeaderRow = true
+	v.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("", func() {})
+	}
+	v.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		button := o.(*widget.Button)
+
+		v.mu.Lock()
+		if id.Col < 0 || id.Col >= len(v.visibleCols) {
+			v.mu.Unlock()
+			button.SetText("")
+			return
+		}
+		col := v.visibleCols[id.Col]
+		text := v.list[0][col]
+		var icon fyne.Resource
+		if col == v.sortCol {
+			if v.sortDesc {
+				icon = theme.MenuDropDownIcon()
+			} else {
+				icon = theme.MenuDropUpIcon()
+			}
+		}
+		v.mu.Unlock()
+
+		button.SetText(text)
+		button.SetIcon(icon)
+		button.OnTapped = func() { v.sortBy(col) }
+	}
+
+	v.footer = widget.NewLabel("")
+	v.footer.TextStyle.Bold = true
+
+	v.refresh()
+
+	return v
+}
+
+func (v *ReportViewer) getPrefsKey(setting string) string {
+	return "reportViewer." + v.prefsName + "." + setting
+}
+
+func (v *ReportViewer) getContent() fyne.CanvasObject {
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("Filter rows...")
+	filterEntry.OnChanged = func(text string) {
+		v.mu.Lock()
+		v.filterText = strings.ToLower(strings.TrimSpace(text))
+		v.mu.Unlock()
+
+		v.refresh()
+	}
+
+	toolbar := container.NewBorder(nil, nil, nil,
+		widget.NewButtonWithIcon("Columns", theme.ListIcon(), v.showColumnsDialog),
+		filterEntry,
+	)
+
+	return container.NewBorder(toolbar, v.footer, nil, nil, v.table)
+}
+
+// Build the File menu with the export options of the whole report
+// and of the rows and columns that are shown
+func (v *ReportViewer) getMenu() *fyne.MainMenu {
+	mainMenu := getReportMenu(v.window, v.reporter, func() [][]string {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		return v.list
+	}, v.name)
+
+	fileMenu := mainMenu.Items[0]
+	fileMenu.Items = append(fileMenu.Items,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Export visible rows as .csv", func() {
+			saveReport(v.window, v.getVisibleReporter(), v.getVisibleList(), v.name, csvFormat)
+		}),
+		fyne.NewMenuItem("Export visible rows as .xlsx", func() {
+			saveReport(v.window, v.getVisibleReporter(), v.getVisibleList(), v.name, xlsxFormat)
+		}),
+	)
+
+	return mainMenu
+}
+
+// The header and the filtered rows restricted to the visible columns
+func (v *ReportViewer) getVisibleList() [][]string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	visibleList := make([][]string, 0, len(v.rows)+1)
+
+	for _, row := range append([][]string{v.list[0]}, v.rows...) {
+		visibleRow := make([]string, 0, len(v.visibleCols))
+		for _, col := range v.visibleCols {
+			visibleRow = append(visibleRow, row[col])
+		}
+		visibleList = append(visibleList, visibleRow)
+	}
+
+	return visibleList
+}
+
+func (v *ReportViewer) getVisibleReporter() Reporter {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var columnTypes []ColumnType
+	for _, col := range v.visibleCols {
+		columnTypes = append(columnTypes, getColumnType(v.columnTypes, col))
+	}
+
+	return visibleColumnsReporter{Reporter: v.reporter, columnTypes: columnTypes}
+}
+
+// Run the report again with the same filter, keeping the sorting and the columns
+func (v *ReportViewer) reload() {
+	list := v.reporter.getReportList()
+
+	v.mu.Lock()
+	if len(list) == 0 || len(list[0]) != len(v.list[0]) {
+		v.mu.Unlock()
+		return
+	}
+	v.list = list
+	v.mu.Unlock()
+
+	v.refresh()
+}
+
+func (v *ReportViewer) sortBy(col int) {
+	v.mu.Lock()
+	if v.sortCol == col {
+		v.sortDesc = !v.sortDesc
+	} else {
+		v.sortCol = col
+		v.sortDesc = false
+	}
+	v.mu.Unlock()
+
+	v.refresh()
+}
+
+// Apply the filter, the sorting and the columns to the table.
+// The widgets are updated after the view is built, outside of the lock
+// the table callbacks take.
+func (v *ReportViewer) refresh() {
+	v.mu.Lock()
+	v.visibleCols = v.visibleCols[:0]
+	for c := range v.list[0] {
+		if !v.hidden[c] {
+			v.visibleCols = append(v.visibleCols, c)
+		}
+	}
+
+	v.rows = v.rows[:0]
+	for _, row := range v.list[1:] {
+		if v.matchesFilter(row) {
+			v.rows = append(v.rows, row)
+		}
+	}
+
+	if v.sortCol >= 0 {
+		colType := getColumnType(v.columnTypes, v.sortCol)
+		sort.SliceStable(v.rows, func(i, j int) bool {
+			if v.sortDesc {
+				return lessCells(v.rows[j][v.sortCol], v.rows[i][v.sortCol], colType)
+			}
+			return lessCells(v.rows[i][v.sortCol], v.rows[j][v.sortCol], colType)
+		})
+	}
+
+	widths := make([]float32, len(v.visibleCols))
+	for i, col := range v.visibleCols {
+		widths[i] = float32(v.widths[col])
+	}
+	summary := v.getSummary()
+	v.mu.Unlock()
+
+	for i, width := range widths {
+		v.table.SetColumnWidth(i, width)
+	}
+
+	v.footer.SetText(summary)
+	v.table.Refresh()
+}
+
+func (v *ReportViewer) matchesFilter(row []string) bool {
+	if v.filterText == "" {
+		return true
+	}
+
+	for c, value := range row {
+		if !v.hidden[c] && strings.Contains(strings.ToLower(value), v.filterText) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The number of the shown rows and the totals of their quantity and value columns
+func (v *ReportViewer) getSummary() string {
+	summary := []string{"Rows: " + strconv.Itoa(len(v.rows)) + " of " + strconv.Itoa(len(v.list)-1)}
+
+	for _, col := range v.visibleCols {
+		colType := getColumnType(v.columnTypes, col)
+		if colType != QuantityColumn && colType != ValueColumn {
+			continue
+		}
+
+		total := decimal.Zero
+		for _, row := range v.rows {
+			if number, err := parseMoney(row[col]); err == nil {
+				total = total.Add(number)
+			}
+		}
+
+		if colType == ValueColumn {
+			summary = append(summary, v.list[0][col]+": "+formatMoney(total))
+		} else {
+			summary = append(summary, v.list[0][col]+": "+accounting.FormatNumberDecimal(total, 0, ",", "."))
+		}
+	}
+
+	return strings.Join(summary, "    ")
+}
+
+// Show or hide the columns and set their widths
+func (v *ReportViewer) showColumnsDialog() {
+	items := []fyne.CanvasObject{}
+
+	v.mu.Lock()
+	header := v.list[0]
+	hidden := slices.Clone(v.hidden)
+	widths := slices.Clone(v.widths)
+	v.mu.Unlock()
+
+	for c, name := range header {
+		col := c
+
+		visibleChk := widget.NewCheck(name, nil)
+		visibleChk.SetChecked(!hidden[col])
+		visibleChk.OnChanged = func(checked bool) {
+			v.mu.Lock()
+			v.hidden[col] = !checked
+			v.mu.Unlock()
+
+			v.savePreferences()
+			v.refresh()
+		}
+
+		widthSlider := widget.NewSlider(50, 500)
+		widthSlider.Step = 10
+		widthSlider.SetValue(widths[col])
+		widthSlider.OnChangeEnded = func(width float64) {
+			v.mu.Lock()
+			v.widths[col] = width
+			v.mu.Unlock()
+
+			v.savePreferences()
+			v.refresh()
+		}
+
+		items = append(items, container.NewGridWithColumns(2, visibleChk, widthSlider))
+	}
+
+	resetButton := widget.NewButton("Reset", func() {
+		v.mu.Lock()
+		for c := range v.widths {
+			v.widths[c] = defaultColumnWidth
+			v.hidden[c] = false
+		}
+		v.mu.Unlock()
+
+		v.savePreferences()
+		v.refresh()
+	})
+
+	content := container.NewBorder(nil, resetButton, nil, nil,
+		container.NewVScroll(container.NewVBox(items...)))
+
+	columnsDialog := dialog.NewCustom("Columns", "Close", content, v.window)
+	columnsDialog.Resize(fyne.NewSize(500, 500))
+	columnsDialog.Show()
+}
+
+func (v *ReportViewer) savePreferences() {
+	v.mu.Lock()
+	widths := slices.Clone(v.widths)
+	hidden := slices.Clone(v.hidden)
+	v.mu.Unlock()
+
+	prefs := fyne.CurrentApp().Preferences()
+	prefs.SetFloatList(v.getPrefsKey("widths"), widths)
+	prefs.SetBoolList(v.getPrefsKey("hidden"), hidden)
+}
+
+// Compare two cells by the type of their column
+func lessCells(a string, b string, colType ColumnType) bool {
+	switch colType {
+	case NumberColumn, QuantityColumn, PriceColumn, AmountColumn, ValueColumn:
+		numberA, errA := parseMoney(a)
+		numberB, errB := parseMoney(b)
+		if errA == nil && errB == nil {
+			return numberA.LessThan(numberB)
+		}
+		// Empty and text values go last
+		if errA == nil || errB == nil {
+			return errA == nil
+		}
+	case DateColumn:
+		dateA, errA := time.Parse("1/2/2006", a)
+		dateB, errB := time.Parse("1/2/2006", b)
+		if errA == nil && errB == nil {
+			return dateA.Before(dateB)
+		}
+		if errA == nil || errB == nil {
+			return errA == nil
+		}
+	}
+
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+// Open a report in a new window with the report viewer.
+// The report is reloaded when the stock is changed from any workstation.
+func showReportWindow(app fyne.App, title string, r Reporter, list [][]string, name string, size fyne.Size) (fyne.Window, *ReportViewer) {
+	window := app.NewWindow(title)
+	viewer := newReportViewer(window, r, list, name)
+
+	unsubscribe := changes.subscribe(viewer.reload, materialsTable, transactionsTable, reservationsTable)
+	window.SetOnClosed(unsubscribe)
+
+	window.SetMainMenu(viewer.getMenu())
+	window.SetContent(viewer.getContent())
+	window.Resize(size)
+	window.Show()
+
+	return window, viewer
+}
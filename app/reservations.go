@@ -0,0 +1,374 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Quantity of a customer stock ID reserved for a job ticket
+type Reservation struct {
+	id           int
+	customerID   int
+	customerName string
+	stockID      string
+	jobTicket    string
+	quantity     int
+	consumed     int
+	requiredDate sql.NullTime
+	notes        string
+	createdAt    time.Time
+}
+
+func (r Reservation) getRemaining() int {
+	return max(r.quantity-r.consumed, 0)
+}
+
+// On-hand and open reserved quantities of a customer stock ID
+type StockAvailability struct {
+	onHand   int
+	reserved int
+}
+
+// Available-to-promise quantity
+func (a StockAvailability) getAvailable() int {
+	return a.onHand - a.reserved
+}
+
+// Unreserved usage that takes the material reserved by other jobs
+type ReservationConflict struct {
+	shortfall  int
+	jobTickets []string
+}
+
+type ReservationReport struct {
+	Report
+	resFilter SearchFilter
+}
+
+// Open reservations, the earliest required first
+func fetchReservations(db *sql.DB, filter SearchFilter) ([]Reservation, error) {
+	rows, err := db.Query(`
+		SELECT r.reservation_id, r.customer_id, c.name, r.stock_id, r.job_ticket,
+			r.quantity, r.consumed_quantity, r.required_date, COALESCE(r.notes, ''), r.created_at
+		FROM reservations r
+		JOIN customers c ON c.customer_id = r.customer_id
+		WHERE r.quantity > r.consumed_quantity AND
+			($1 = '' OR r.stock_id = $1) AND
+			($2 = 0 OR r.customer_id = $2)
+		ORDER BY r.required_date NULLS LAST, r.created_at;`,
+		filter.stockID, filter.customerID)
+	if err != nil {
+		log.Println("Error fetchReservations1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []Reservation
+
+	for rows.Next() {
+		var r Reservation
+		if err := rows.Scan(&r.id, &r.customerID, &r.customerName, &r.stockID, &r.jobTicket,
+			&r.quantity, &r.consumed, &r.requiredDate, &r.notes, &r.createdAt); err != nil {
+			log.Println("Error fetchReservations2: ", err)
+			return reservations, err
+		}
+		reservations = append(reservations, r)
+	}
+
+	return reservations, rows.Err()
+}
+
+func fetchStockAvailability(db queryExecutor, customerID int, stockID string) (StockAvailability, error) {
+	var a StockAvailability
+
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COALESCE(SUM(quantity), 0) FROM materials
+			 WHERE customer_id = $1 AND stock_id = $2),
+			(SELECT COALESCE(SUM(quantity - consumed_quantity), 0) FROM reservations
+			 WHERE customer_id = $1 AND stock_id = $2 AND quantity > consumed_quantity);`,
+		customerID, stockID).Scan(&a.onHand, &a.reserved)
+	if err != nil {
+		log.Println("Error fetchStockAvailability: ", err)
+	}
+
+	return a, err
+}
+
+// Availability by customer and stock ID, see getForecastKey
+func fetchAvailabilityMap(db *sql.DB, customerID int) (map[string]StockAvailability, error) {
+	availabilityMap := make(map[string]StockAvailability)
+
+	rows, err := db.Query(`
+		SELECT c.name, s.stock_id, SUM(s.on_hand), SUM(s.reserved)
+		FROM (
+			SELECT customer_id, stock_id, quantity AS on_hand, 0 AS reserved
+			FROM materials
+			UNION ALL
+			SELECT customer_id, stock_id, 0, quantity - consumed_quantity
+			FROM reservations
+			WHERE quantity > consumed_quantity
+		) s
+		JOIN customers c ON c.customer_id = s.customer_id
+		WHERE ($1 = 0 OR s.customer_id = $1)
+		GROUP BY c.name, s.stock_id;`, customerID)
+	if err != nil {
+		log.Println("Error fetchAvailabilityMap1: ", err)
+		return availabilityMap, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var customerName, stockID string
+		var a StockAvailability
+		if err := rows.Scan(&customerName, &stockID, &a.onHand, &a.reserved); err != nil {
+			log.Println("Error fetchAvailabilityMap2: ", err)
+			return availabilityMap, err
+		}
+		availabilityMap[getForecastKey(customerName, stockID)] = a
+	}
+
+	return availabilityMap, rows.Err()
+}
+
+// Reserve a quantity that is available to promise
+func addReservation(db *sql.DB, r Reservation) error {
+	if r.customerID == 0 || strings.TrimSpace(r.stockID) == "" || strings.TrimSpace(r.jobTicket) == "" {
+		return errors.New("The customer, the stock ID and the job ticket are required")
+	}
+	if r.quantity <= 0 {
+		return errors.New("The quantity must be a positive number")
+	}
+
+	if err := checkJobOpen(db, r.jobTicket); err != nil {
+		return err
+	}
+
+	return withTransaction(db, func(tx *sql.Tx) error {
+		// One reservation at a time for the stock ID of the customer
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1));`,
+			strconv.Itoa(r.customerID)+"|"+r.stockID); err != nil {
+			log.Println("Error addReservation1: ", err)
+			return err
+		}
+
+		a, err := fetchStockAvailability(tx, r.customerID, r.stockID)
+		if err != nil {
+			return err
+		}
+		if a.getAvailable() < r.quantity {
+			return errors.New("Only " + strconv.Itoa(max(a.getAvailable(), 0)) + " of " + r.stockID +
+				" are available: " + strconv.Itoa(a.onHand) + " on hand, " + strconv.Itoa(a.reserved) + " reserved")
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO reservations (customer_id, stock_id, job_ticket, quantity, required_date, notes)
+			VALUES ($1, $2, $3, $4, $5, $6);`,
+			r.customerID, r.stockID, r.jobTicket, r.quantity, r.requiredDate, nullString(r.notes)); err != nil {
+			log.Println("Error addReservation2: ", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Release the remaining quantity, the consumed part is kept for the history
+func cancelReservation(db *sql.DB, reservationID int) error {
+	_, err := db.Exec(`DELETE FROM reservations WHERE reservation_id = $1 AND consumed_quantity = 0;`, reservationID)
+	if err == nil {
+		_, err = db.Exec(`UPDATE reservations SET quantity = consumed_quantity WHERE reservation_id = $1;`, reservationID)
+	}
+	if err != nil {
+		log.Println("Error cancelReservation: ", err)
+	}
+
+	return err
+}
+
+// Consume the open reservations of the job ticket by the used quantity,
+// the consumed quantity is returned
+func consumeReservations(db queryExecutor, customerID int, stockID string, jobTicket string, quantity int) (int, error) {
+	if jobTicket == "" {
+		return 0, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT reservation_id, quantity - consumed_quantity
+		FROM reservations
+		WHERE customer_id = $1 AND stock_id = $2 AND job_ticket = $3 AND quantity > consumed_quantity
+		ORDER BY required_date NULLS LAST, created_at
+		FOR UPDATE;`,
+		customerID, stockID, jobTicket)
+	if err != nil {
+		log.Println("Error consumeReservations1: ", err)
+		return 0, err
+	}
+
+	var reservations []Reservation
+	for rows.Next() {
+		var r Reservation
+		if err := rows.Scan(&r.id, &r.quantity); err != nil {
+			rows.Close()
+			log.Println("Error consumeReservations2: ", err)
+			return 0, err
+		}
+		reservations = append(reservations, r)
+	}
+	rows.Close()
+
+	consumed := 0
+	for _, r := range reservations {
+		if consumed == quantity {
+			break
+		}
+		consumeQty := min(r.quantity, quantity-consumed)
+		if _, err := db.Exec(`UPDATE reservations SET consumed_quantity = consumed_quantity + $1
+							  WHERE reservation_id = $2;`, consumeQty, r.id); err != nil {
+			log.Println("Error consumeReservations3: ", err)
+			return consumed, err
+		}
+		consumed += consumeQty
+	}
+
+	return consumed, nil
+}
+
+// The part of a usage that is not reserved by the job ticket and
+// is not available to promise either, nil when there is no conflict
+func getReservationConflict(db *sql.DB, customerID int, stockID string, jobTicket string, quantity int) (*ReservationConflict, error) {
+	a, err := fetchStockAvailability(db, customerID, stockID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ownReserved int
+	if err := db.QueryRow(`
+		SELECT COALESCE(SUM(quantity - consumed_quantity), 0)
+		FROM reservations
+		WHERE customer_id = $1 AND stock_id = $2 AND job_ticket = $3 AND quantity > consumed_quantity;`,
+		customerID, stockID, jobTicket).Scan(&ownReserved); err != nil {
+		log.Println("Error getReservationConflict1: ", err)
+		return nil, err
+	}
+
+	unreserved := max(quantity-ownReserved, 0)
+	shortfall := unreserved - max(a.getAvailable(), 0)
+	if shortfall <= 0 {
+		return nil, nil
+	}
+
+	conflict := &ReservationConflict{shortfall: shortfall}
+
+	rows, err := db.Query(`
+		SELECT DISTINCT job_ticket
+		FROM reservations
+		WHERE customer_id = $1 AND stock_id = $2 AND job_ticket <> $3 AND quantity > consumed_quantity
+		ORDER BY job_ticket;`,
+		customerID, stockID, jobTicket)
+	if err != nil {
+		log.Println("Error getReservationConflict2: ", err)
+		return conflict, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticket string
+		if err := rows.Scan(&ticket); err != nil {
+			log.Println("Error getReservationConflict3: ", err)
+			return conflict, err
+		}
+		conflict.jobTickets = append(conflict.jobTickets, ticket)
+	}
+
+	return conflict, rows.Err()
+}
+
+func (r ReservationReport) getReportList() [][]string {
+	resList := [][]string{
+		{
+			"Customer", "Stock ID", "Job Ticket", "Required Date", "Reserved", "Consumed",
+			"Remaining", "On Hand", "Available", "Created", "Notes",
+		},
+	}
+
+	reservations, _ := fetchReservations(r.db, r.resFilter)
+	availabilityMap, _ := fetchAvailabilityMap(r.db, r.resFilter.customerID)
+
+	for _, reservation := range reservations {
+		a := availabilityMap[getForecastKey(reservation.customerName, reservation.stockID)]
+
+		resList = append(resList, []string{
+			reservation.customerName,
+			reservation.stockID,
+			reservation.jobTicket,
+			formatNullDate(reservation.requiredDate),
+			strconv.Itoa(reservation.quantity),
+			strconv.Itoa(reservation.consumed),
+			strconv.Itoa(reservation.getRemaining()),
+			strconv.Itoa(a.onHand),
+			strconv.Itoa(a.getAvailable()),
+			formatReportDate(reservation.createdAt),
+			reservation.notes,
+		})
+	}
+
+	return resList
+}
+
+func (r ReservationReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, TextColumn, DateColumn, QuantityColumn, QuantityColumn,
+		QuantityColumn, QuantityColumn, QuantityColumn, DateColumn, TextColumn,
+	}
+}
+
+func (r ReservationReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Open Reservations",
+		customerName: r.resFilter.customerName,
+		period:       "As of " + formatReportDate(time.Now()),
+	}
+}
+
+func (r ReservationReport) showReport() {
+	customers, _ := fetchCustomers(r.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	stockIDInput := widget.NewEntry()
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+
+	dialog := dialog.NewForm("Filter Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Stock ID", stockIDInput),
+			widget.NewFormItem("Customer", customerSelector),
+		}, func(confirm bool) {
+			if confirm {
+				r.resFilter = SearchFilter{
+					stockID:      strings.TrimSpace(stockIDInput.Text),
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+				}
+
+				resList := r.getReportList()
+				showReportWindow(r.app, "Reservations", r, resList,
+					"reservations_"+time.Now().Format("2006-01-02"), fyne.NewSize(1300, 600))
+			}
+		}, r.window)
+
+	dialog.Resize(fyne.NewSize(500, 200))
+	dialog.Show()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// The snapshot of the previous day is taken every night
+const snapshotSchedule = "10 0 * * *"
+
+const snapshotDateLayout = "2006-01-02"
+
+// Save the end of day stock of every material, the day is skipped
+// when another workstation has already taken it
+func takeInventorySnapshot(db *sql.DB, day time.Time) error {
+	date := day.Format(snapshotDateLayout)
+
+	return withTransaction(db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('inventory_snapshots'));`); err != nil {
+			log.Println("Error takeInventorySnapshot1: ", err)
+			return err
+		}
+
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM inventory_snapshots WHERE snapshot_date = $1::date);`,
+			date).Scan(&exists); err != nil {
+			log.Println("Error takeInventorySnapshot2: ", err)
+			return err
+		}
+		if exists {
+			return nil
+		}
+
+		// The balances roll forward from the snapshot of the day before
+		if _, err := tx.Exec(`
+			INSERT INTO inventory_snapshots
+				(snapshot_date, material_id, stock_id, location_id, customer_id, owner,
+				material_type, quantity, value)
+			SELECT $1::date, b.material_id, b.stock_id, b.location_id, b.customer_id, b.owner,
+				b.material_type, b.quantity, b.value
+			FROM material_balances($1::date + 1) b
+			WHERE b.quantity <> 0;`, date); err != nil {
+			log.Println("Error takeInventorySnapshot3: ", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Take the missing snapshots up to yesterday, starting from the first
+// transaction when there are none yet. The number of the new days is returned.
+func updateInventorySnapshots(db *sql.DB, now time.Time) (int, error) {
+	var start sql.NullTime
+	err := db.QueryRow(`
+		SELECT COALESCE(
+			(SELECT MAX(snapshot_date) + 1 FROM inventory_snapshots)::timestamp,
+			(SELECT MIN(updated_at) FROM transactions_log));`).Scan(&start)
+	if err != nil {
+		log.Println("Error updateInventorySnapshots: ", err)
+		return 0, err
+	}
+	if !start.Valid {
+		return 0, nil
+	}
+
+	day := time.Date(start.Time.Year(), start.Time.Month(), start.Time.Day(), 0, 0, 0, 0, time.Local)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	var taken int
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if err := takeInventorySnapshot(db, day); err != nil {
+			return taken, err
+		}
+		taken++
+	}
+
+	return taken, nil
+}
+
+// Catch up the snapshots in the background, e.g. after the app was closed for days
+func startInventorySnapshots(db *sql.DB) {
+	go func() {
+		taken, err := updateInventorySnapshots(db, time.Now())
+		if err != nil {
+			log.Println("Error startInventorySnapshots: ", err)
+			return
+		}
+		if taken > 0 {
+			log.Println("Inventory snapshots taken:", taken)
+		}
+	}()
+}
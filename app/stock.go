@@ -0,0 +1,260 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Put an incoming shipment to a location and remove it from the incoming list
+func acceptStock(db *sql.DB, materialOpts *MaterialOpts, locationID int, quantity int, notes string) error {
+	return withTransaction(db, func(tx *sql.Tx) error {
+		var materialID int
+
+		itemID, err := ensureItem(tx, materialOpts)
+		if err != nil {
+			return err
+		}
+
+		// Update the item of the customer in the current location
+		err = tx.QueryRow(`
+			UPDATE materials
+			SET quantity = (quantity + $1)
+			WHERE item_id = $2
+				AND location_id = $3
+				AND owner = $4
+			RETURNING material_id;`,
+			quantity, itemID, locationID, materialOpts.owner,
+		).Scan(&materialID)
+		if err != nil && err != sql.ErrNoRows {
+			log.Println("Error acceptStock1: ", err)
+			return err
+		}
+
+		// If there is no the same material in the current location
+		// Then add the material in the chosen one
+		if materialID == 0 {
+			err := tx.QueryRow(`
+				INSERT INTO materials
+					(item_id, stock_id, location_id, customer_id, notes,
+					quantity, updated_at, cost, owner)
+				SELECT item_id, stock_id, $2, customer_id, $3, $4, $5, $6, $7
+				FROM items
+				WHERE item_id = $1
+				RETURNING material_id;`,
+				itemID,
+				locationID,
+				notes,
+				quantity,
+				time.Now(),
+				materialOpts.cost,
+				materialOpts.owner,
+			).Scan(&materialID)
+			if err != nil {
+				log.Println("Error acceptStock2: ", err)
+				return errors.New("Unable to save data: " + err.Error())
+			}
+		}
+
+		// Remove the material from incoming
+		if err := deleteIncomingMaterial(tx, materialOpts.shippingId); err != nil {
+			log.Println("Error acceptStock3: ", err)
+			return errors.New("Deleting incoming material: " + err.Error())
+		}
+
+		if _, err := addTranscation(&TransactionInfo{
+			materialId: materialID,
+			stockId:    materialOpts.stockID,
+			quantity:   quantity,
+			notes:      notes,
+			updatedAt:  time.Now(),
+			cost:       materialOpts.cost,
+			trxType:    receiptTrx,
+		}, tx); err != nil {
+			log.Println("Error acceptStock4: ", err)
+			return errors.New("Updating transactions error: " + err.Error())
+		}
+
+		return nil
+	})
+}
+
+// Use a quantity of a material for a job. The remaining quantity and
+// the value of the used quantity at the costs of its layers are returned.
+func useStock(db *sql.DB, materialID int, quantity int, jobTicket string, notes string) (int, decimal.Decimal, error) {
+	var remaining int
+	var value decimal.Decimal
+
+	err := withTransaction(db, func(tx *sql.Tx) error {
+		var err error
+		remaining, value, err = issueStock(tx, materialID, quantity, jobTicket, notes)
+		return err
+	})
+
+	return remaining, value, err
+}
+
+// Use a quantity of a material in the transaction of the caller
+func issueStock(db queryExecutor, materialID int, quantity int, jobTicket string, notes string) (int, decimal.Decimal, error) {
+	if err := checkJobOpen(db, jobTicket); err != nil {
+		return 0, decimal.Zero, err
+	}
+
+	var stockID string
+	var actualQuantity, customerID int
+	err := db.QueryRow(`
+		SELECT stock_id, quantity, COALESCE(customer_id, 0)
+		FROM materials
+		WHERE material_id = $1
+		FOR UPDATE;`, materialID).Scan(&stockID, &actualQuantity, &customerID)
+	if err != nil {
+		log.Println("Error issueStock1: ", err)
+		return 0, decimal.Zero, err
+	}
+
+	// Verify that we have the remaining materials
+	if actualQuantity < quantity {
+		return actualQuantity, decimal.Zero, errors.New(`The removing quantity (` + strconv.Itoa(quantity) +
+			`) is more than the actual one (` + strconv.Itoa(actualQuantity) + `)`)
+	}
+
+	// The material is kept with zero quantity, the history refers to it
+	_, err = db.Exec(`
+		UPDATE materials
+		SET quantity = (quantity - $1),
+			notes = $2
+		WHERE material_id = $3;`,
+		quantity, notes, materialID,
+	)
+	if err != nil {
+		log.Println("Error issueStock2: ", err)
+		return actualQuantity, decimal.Zero, errors.New("Updating material error: " + err.Error())
+	}
+
+	value, err := addTranscation(&TransactionInfo{
+		materialId: materialID,
+		stockId:    stockID,
+		quantity:   -quantity,
+		notes:      notes,
+		jobTicket:  jobTicket,
+		updatedAt:  time.Now(),
+		trxType:    usageTrx,
+	}, db)
+	if err != nil {
+		log.Println("Error issueStock3: ", err)
+		return actualQuantity - quantity, value, errors.New("Updating transactions error: " + err.Error())
+	}
+
+	// The reservations of the job are used first
+	if _, err := consumeReservations(db, customerID, stockID, jobTicket, quantity); err != nil {
+		log.Println("Error issueStock4: ", err)
+		return actualQuantity - quantity, value, errors.New("Updating reservations error: " + err.Error())
+	}
+
+	return actualQuantity - quantity, value, nil
+}
+
+// Move a quantity of a material to another location
+func moveStock(db *sql.DB, materialID int, newLocationID int, quantity int, notes string) error {
+	return withTransaction(db, func(tx *sql.Tx) error {
+		var currMaterial MaterialInfo
+
+		var actualQuantity int
+		if err := tx.QueryRow(`SELECT quantity FROM materials WHERE material_id = $1 FOR UPDATE`, materialID).
+			Scan(&actualQuantity); err != nil {
+			log.Println("Error moveStock1: ", err)
+			return err
+		}
+
+		// Check whether remaining quantity exists
+		if actualQuantity < quantity {
+			return errors.New(`The moving quantity (` + strconv.Itoa(quantity) +
+				`) is more than the actual one (` + strconv.Itoa(actualQuantity) + `)`)
+		}
+
+		// Update material in the current location
+		err := tx.QueryRow(`
+			UPDATE materials
+			SET quantity = (quantity - $1),
+				notes = $2
+			WHERE material_id = $3
+			RETURNING material_id, item_id, stock_id, location_id, customer_id,
+					notes, quantity, updated_at, cost, owner;`,
+			quantity, notes, materialID,
+		).Scan(
+			&currMaterial.materialId,
+			&currMaterial.itemId,
+			&currMaterial.stockId,
+			&currMaterial.locationId,
+			&currMaterial.customerId,
+			&currMaterial.notes,
+			&currMaterial.quantity,
+			&currMaterial.updatedAt,
+			&currMaterial.cost,
+			&currMaterial.owner,
+		)
+		if err != nil {
+			log.Println("Error moveStock2: ", err)
+			return err
+		}
+
+		// Update the item of the customer in the new location
+		var newMaterialID int
+		err = tx.QueryRow(`
+			UPDATE materials
+			SET quantity = (quantity + $1)
+			WHERE
+				item_id = $2 AND
+				location_id = $3 AND
+				owner = $4
+			RETURNING material_id;`,
+			quantity, currMaterial.itemId, newLocationID, currMaterial.owner,
+		).Scan(&newMaterialID)
+		if err != nil && err != sql.ErrNoRows {
+			log.Println("Error moveStock3: ", err)
+			return err
+		}
+
+		// If there is no the material in the destination location
+		// Then add the material in there
+		if newMaterialID == 0 {
+			err := tx.QueryRow(`
+				INSERT INTO materials
+					(item_id, stock_id, location_id,
+					customer_id, notes, quantity, updated_at, cost, owner)
+					VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+					RETURNING material_id;`,
+				currMaterial.itemId, currMaterial.stockId, newLocationID,
+				currMaterial.customerId, currMaterial.notes, quantity, time.Now(),
+				currMaterial.cost, currMaterial.owner).
+				Scan(&newMaterialID)
+			if err != nil {
+				log.Println("Error moveStock4: ", err)
+				return err
+			}
+		}
+
+		if _, err := addTranscation(&TransactionInfo{
+			materialId:     currMaterial.materialId,
+			stockId:        currMaterial.stockId,
+			quantity:       -quantity,
+			notes:          notes,
+			cost:           currMaterial.cost,
+			updatedAt:      time.Now(),
+			trxType:        moveTrx,
+			isMove:         true,
+			newMaterialId:  newMaterialID,
+			fromLocationId: currMaterial.locationId,
+			toLocationId:   newLocationID,
+		}, tx); err != nil {
+			log.Println("Error moveStock5: ", err)
+			return errors.New("Updating transactions error: " + err.Error())
+		}
+
+		return nil
+	})
+}
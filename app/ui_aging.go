@@ -0,0 +1,343 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+type AgingBucket struct {
+	name    string
+	maxDays int
+}
+
+var agingBuckets = []AgingBucket{
+	{"0-30", 30},
+	{"31-90", 90},
+	{"91-180", 180},
+	{"181-365", 365},
+	{"365+", math.MaxInt},
+}
+
+type AgingReport struct {
+	Report
+	agnFilter SearchFilter
+}
+
+// Proposal to return or destroy the materials of a customer
+// that have not been touched for minDays
+type DisposalProposal struct {
+	AgingReport
+	minDays int
+}
+
+type AgingRow struct {
+	customerName  string
+	stockID       string
+	materialType  string
+	owner         string
+	warehouseName string
+	locationName  string
+	lastReceipt   sql.NullTime
+	lastUsage     sql.NullTime
+	lastEntry     time.Time
+	qty           int
+	value         decimal.Decimal
+}
+
+func fetchAgingRows(db *sql.DB, filter SearchFilter) ([]AgingRow, error) {
+	rows, err := db.Query(`
+	SELECT COALESCE(c.name, ''),
+		   m.stock_id,
+		   i.material_type,
+		   m.owner,
+		   COALESCE(w.name, ''),
+		   COALESCE(l.name, ''),
+		   MAX(tl.updated_at) FILTER (WHERE tl.transaction_type = 'Receipt') AS last_receipt,
+		   MAX(tl.updated_at) FILTER (WHERE tl.transaction_type = 'Usage') AS last_usage,
+		   MAX(tl.updated_at) AS last_entry,
+		   SUM(tl.quantity_change) AS "quantity",
+		   SUM(tl.quantity_change * tl.cost) AS "total_value"
+	FROM transactions_log tl
+	JOIN materials m ON m.material_id = tl.material_id
+	JOIN items i ON i.item_id = m.item_id
+	LEFT JOIN customers c ON c.customer_id = m.customer_id
+	LEFT JOIN locations l ON l.location_id = m.location_id
+	LEFT JOIN warehouses w ON w.warehouse_id = l.warehouse_id
+	WHERE
+		($1 = 0 OR m.customer_id = $1) AND
+		($2 = '' OR m.owner::TEXT = $2) AND
+		($3 = '' OR i.material_type::TEXT = $3)
+	GROUP BY m.material_id, c.name, m.stock_id, i.material_type, m.owner, w.name, l.name
+	HAVING SUM(tl.quantity_change) > 0
+	ORDER BY c.name, m.stock_id;`,
+		filter.customerID, filter.owner, filter.materialType,
+	)
+	if err != nil {
+		log.Println("Error fetchAgingRows1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agingRows []AgingRow
+
+	for rows.Next() {
+		var row AgingRow
+		if err := rows.Scan(
+			&row.customerName,
+			&row.stockID,
+			&row.materialType,
+			&row.owner,
+			&row.warehouseName,
+			&row.locationName,
+			&row.lastReceipt,
+			&row.lastUsage,
+			&row.lastEntry,
+			&row.qty,
+			&row.value,
+		); err != nil {
+			log.Println("Error fetchAgingRows2: ", err)
+			return agingRows, err
+		}
+		agingRows = append(agingRows, row)
+	}
+	if err = rows.Err(); err != nil {
+		return agingRows, err
+	}
+
+	return agingRows, nil
+}
+
+// Days since the last receipt or usage. Materials that were only moved
+// or adjusted are aged from their last entry.
+func (r AgingRow) getIdleDays() int {
+	lastActivity := r.lastEntry
+	if r.lastReceipt.Valid || r.lastUsage.Valid {
+		lastActivity = time.Time{}
+		if r.lastReceipt.Valid {
+			lastActivity = r.lastReceipt.Time
+		}
+		if r.lastUsage.Valid && r.lastUsage.Time.After(lastActivity) {
+			lastActivity = r.lastUsage.Time
+		}
+	}
+
+	return int(time.Since(lastActivity).Hours() / 24)
+}
+
+func formatNullDate(date sql.NullTime) string {
+	if !date.Valid {
+		return "Never"
+	}
+
+	return date.Time.Format("1/2/2006")
+}
+
+func (a AgingReport) getReportList() [][]string {
+	header := []string{
+		"Customer", "Stock ID", "Material Type", "Owner", "Warehouse", "Location",
+		"Last Receipt", "Last Usage", "Days Idle",
+	}
+	for _, bucket := range agingBuckets {
+		header = append(header, bucket.name+" Qty", bucket.name+" Value")
+	}
+
+	agnList := [][]string{header}
+
+	agingRows, _ := fetchAgingRows(a.db, a.agnFilter)
+
+	for _, row := range agingRows {
+		idleDays := row.getIdleDays()
+
+		line := []string{
+			row.customerName,
+			row.stockID,
+			row.materialType,
+			row.owner,
+			row.warehouseName,
+			row.locationName,
+			formatNullDate(row.lastReceipt),
+			formatNullDate(row.lastUsage),
+			strconv.Itoa(idleDays),
+		}
+
+		bucketFound := false
+		for _, bucket := range agingBuckets {
+			if !bucketFound && idleDays <= bucket.maxDays {
+				line = append(line, strconv.Itoa(row.qty), formatMoney(row.value))
+				bucketFound = true
+			} else {
+				line = append(line, "", "")
+			}
+		}
+
+		agnList = append(agnList, line)
+	}
+
+	return agnList
+}
+
+func (a AgingReport) getColumnTypes() []ColumnType {
+	columnTypes := []ColumnType{
+		TextColumn, TextColumn, TextColumn, TextColumn, TextColumn, TextColumn,
+		DateColumn, DateColumn, NumberColumn,
+	}
+	for range agingBuckets {
+		columnTypes = append(columnTypes, QuantityColumn, ValueColumn)
+	}
+
+	return columnTypes
+}
+
+func (a AgingReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Slow-Moving and Dead Stock Aging",
+		customerName: a.agnFilter.customerName,
+		period:       "As of " + time.Now().Format("01/02/2006"),
+	}
+}
+
+func (a AgingReport) showReport() {
+	customers, _ := fetchCustomers(a.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	ownerSelector := widget.NewSelect(owners, func(s string) {})
+	typeSelector := widget.NewSelect(materialTypes, func(s string) {})
+
+	dialog := dialog.NewForm("Filter Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer", customerSelector),
+			widget.NewFormItem("Owner", ownerSelector),
+			widget.NewFormItem("Material Type", typeSelector),
+		}, func(confirm bool) {
+			if confirm {
+				a.agnFilter = SearchFilter{
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+					owner:        ownerSelector.Selected,
+					materialType: typeSelector.Selected,
+				}
+
+				agnList := a.getReportList()
+				window, viewer := showReportWindow(a.app, "Aging Report", a, agnList,
+					"aging_"+time.Now().Format("2006-01-02"), fyne.NewSize(1600, 700))
+
+				mainMenu := viewer.getMenu()
+				mainMenu.Items = append(mainMenu.Items, fyne.NewMenu("Actions",
+					fyne.NewMenuItem("Return or Destroy Proposal", func() {
+						a.showProposal(window)
+					}),
+				))
+				window.SetMainMenu(mainMenu)
+			}
+		}, a.window)
+
+	dialog.Resize(fyne.NewSize(500, 250))
+	dialog.Show()
+}
+
+// Generate the proposal for the customer chosen in the filter
+func (a AgingReport) showProposal(window fyne.Window) {
+	if a.agnFilter.customerID == 0 {
+		dialog.ShowInformation("Error", "Filter the report by a customer to make a proposal", window)
+		return
+	}
+
+	var bucketsStr []string
+	for _, bucket := range agingBuckets[1:] {
+		bucketsStr = append(bucketsStr, bucket.name)
+	}
+	minDaysSelector := widget.NewSelect(bucketsStr, func(s string) {})
+	minDaysSelector.SetSelected("181-365")
+
+	dialog.ShowForm("Return or Destroy Proposal", "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Idle for at least", minDaysSelector),
+		}, func(confirm bool) {
+			if confirm {
+				proposal := DisposalProposal{AgingReport: a}
+				for i, bucket := range agingBuckets[1:] {
+					if bucket.name == minDaysSelector.Selected {
+						proposal.minDays = agingBuckets[i].maxDays + 1
+					}
+				}
+
+				proposalList := proposal.getReportList()
+				if len(proposalList) == 1 {
+					dialog.ShowInformation("Proposal", "No materials idle for "+
+						strconv.Itoa(proposal.minDays)+" days or more", window)
+					return
+				}
+
+				saveReport(window, proposal, proposalList,
+					"proposal_"+safeFileName(a.agnFilter.customerName)+"_"+time.Now().Format("2006-01-02"),
+					pdfFormat)
+			}
+		}, window)
+}
+
+func (p DisposalProposal) getReportList() [][]string {
+	prpList := [][]string{
+		{
+			"Stock ID", "Material Type", "Owner", "Location", "Last Activity",
+			"Days Idle", "Quantity", "Value, USD", "Return / Destroy",
+		},
+	}
+
+	agingRows, _ := fetchAgingRows(p.db, p.agnFilter)
+
+	for _, row := range agingRows {
+		idleDays := row.getIdleDays()
+		if idleDays < p.minDays {
+			continue
+		}
+
+		prpList = append(prpList, []string{
+			row.stockID,
+			row.materialType,
+			row.owner,
+			row.warehouseName + " / " + row.locationName,
+			time.Now().AddDate(0, 0, -idleDays).Format("1/2/2006"),
+			strconv.Itoa(idleDays),
+			strconv.Itoa(row.qty),
+			formatMoney(row.value),
+			"[  ] Return  [  ] Destroy",
+		})
+	}
+
+	return prpList
+}
+
+func (p DisposalProposal) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, TextColumn, TextColumn, DateColumn,
+		NumberColumn, QuantityColumn, ValueColumn, TextColumn,
+	}
+}
+
+func (p DisposalProposal) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title: "Return or Destroy Proposal: materials idle for " +
+			strconv.Itoa(p.minDays) + "+ days",
+		customerName: p.agnFilter.customerName,
+		period:       "As of " + time.Now().Format("01/02/2006"),
+	}
+}
+
+func (p DisposalProposal) showReport() {
+	p.showProposal(p.window)
+}
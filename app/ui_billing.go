@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rate cards of all customers with the form to change them
+func showRateCards(window fyne.Window, db *sql.DB) {
+	rateCards, err := fetchRateCards(db)
+	if err != nil {
+		dialog.ShowInformation("Error", err.Error(), window)
+		return
+	}
+
+	var rateCardsDialog dialog.Dialog
+	items := []fyne.CanvasObject{}
+
+	for _, rateCard := range rateCards {
+		r := rateCard
+		rates := "Location-day " + formatUnitCost(r.locationDayRate) +
+			", unit-month " + formatUnitCost(r.unitMonthRate) +
+			", value " + formatAmount(r.valueMonthPercent) + "% a month" +
+			", receipt " + formatUnitCost(r.receiptFee) +
+			", issue " + formatUnitCost(r.issueFee)
+
+		nameLabel := widget.NewLabel(r.customerName)
+		nameLabel.TextStyle.Bold = true
+
+		items = append(items, container.NewBorder(nil, nil, nameLabel,
+			widget.NewButton("Edit", func() {
+				rateCardsDialog.Hide()
+				editRateCard(window, db, r)
+			}),
+			widget.NewLabel(rates),
+		))
+	}
+
+	rateCardsDialog = dialog.NewCustom("Rate Cards", "Close",
+		container.NewVScroll(container.NewVBox(items...)), window)
+	rateCardsDialog.Resize(fyne.NewSize(1000, 500))
+	rateCardsDialog.Show()
+}
+
+func editRateCard(window fyne.Window, db *sql.DB, r RateCard) {
+	newRateInput := func(value decimal.Decimal) *widget.Entry {
+		input := widget.NewEntry()
+		input.SetText(value.String())
+		return input
+	}
+
+	locationDayInput := newRateInput(r.locationDayRate)
+	unitMonthInput := newRateInput(r.unitMonthRate)
+	valuePercentInput := newRateInput(r.valueMonthPercent)
+	receiptFeeInput := newRateInput(r.receiptFee)
+	issueFeeInput := newRateInput(r.issueFee)
+
+	dialog.ShowForm("Rate Card: "+r.customerName, "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Location-day, USD", locationDayInput),
+			widget.NewFormItem("Unit-month, USD", unitMonthInput),
+			widget.NewFormItem("Value a month, %", valuePercentInput),
+			widget.NewFormItem("Receipt fee, USD", receiptFeeInput),
+			widget.NewFormItem("Issue fee, USD", issueFeeInput),
+		}, func(confirm bool) {
+			if confirm {
+				var errs []error
+				parseRate := func(input *widget.Entry) decimal.Decimal {
+					text := strings.ReplaceAll(strings.TrimSpace(input.Text), ",", "")
+					if text == "" {
+						return decimal.Zero
+					}
+					value, err := decimal.NewFromString(text)
+					if err != nil {
+						errs = append(errs, err)
+					}
+					return value
+				}
+
+				r.locationDayRate = parseRate(locationDayInput)
+				r.unitMonthRate = parseRate(unitMonthInput)
+				r.valueMonthPercent = parseRate(valuePercentInput)
+				r.receiptFee = parseRate(receiptFeeInput)
+				r.issueFee = parseRate(issueFeeInput)
+
+				if len(errs) > 0 {
+					dialog.ShowInformation("Error", "The rates must be numbers", window)
+					return
+				}
+				if err := saveRateCard(db, r); err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+				showRateCards(window, db)
+			}
+		}, window)
+}
@@ -2,10 +2,9 @@ package main
 
 import (
 	"database/sql"
-	"encoding/csv"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -16,65 +15,89 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/leekchan/accounting"
+	"github.com/shopspring/decimal"
 )
 
 // STRUCTS
 /////////////////////////////////
 
 type Material struct {
-	MaterialID     int       `field:"material_id"`
-	StockID        string    `field:"stock_id"`
-	LocationName   string    `field:"location_id"`
-	Description    string    `field:"description"`
-	Notes          string    `field:"notes"`
-	Quantity       int       `field:"quantity"`
-	MinRequiredQty int       `field:"min_required_quantity"`
-	MaxRequiredQty int       `field:"min_required_quantity"`
-	UpdatedAt      time.Time `field:"updated_at"`
-	CustomerName   string    `field:"customer_id"`
-	MaterialType   string    `field:"type"`
-	IsActive       string    `field:"is_active"`
-	Cost           float64   `field:"cost"`
-	Owner          string    `field:"owner"`
+	MaterialID     int             `field:"material_id"`
+	StockID        string          `field:"stock_id"`
+	LocationName   string          `field:"location_id"`
+	Description    string          `field:"description"`
+	Notes          string          `field:"notes"`
+	Quantity       int             `field:"quantity"`
+	MinRequiredQty int             `field:"min_required_quantity"`
+	MaxRequiredQty int             `field:"min_required_quantity"`
+	UpdatedAt      time.Time       `field:"updated_at"`
+	CustomerName   string          `field:"customer_id"`
+	MaterialType   string          `field:"type"`
+	IsActive       string          `field:"is_active"`
+	Cost           decimal.Decimal `field:"cost"`
+	Owner          string          `field:"owner"`
+	Unit           string          `field:"unit"`
+	UnitFactor     int             `field:"factor"`
 }
 
 type Transaction struct {
-	TransactionId int       `field:"transaction_id"`
-	MaterialId    int       `field:"material_id"`
-	StockId       string    `field:"stock_id"`
-	Quantity      int       `field:"quantity_change"`
-	Notes         string    `field:"notes"`
-	Cost          float64   `field:"cost"`
-	UpdatedAt     time.Time `field:"updated_at"`
-	JobTicket     string    `field:"job_ticket"`
-	LocationName  string    `field:"location_name"`
-	WarehouseName string    `field:"warehouse_name"`
-	CustomerName  string    `field:"customer_name"`
-	RemainingQty  int       `field:"remaining_quantity"`
+	TransactionId int             `field:"transaction_id"`
+	MaterialId    int             `field:"material_id"`
+	StockId       string          `field:"stock_id"`
+	Quantity      int             `field:"quantity_change"`
+	Notes         string          `field:"notes"`
+	Cost          decimal.Decimal `field:"cost"`
+	UpdatedAt     time.Time       `field:"updated_at"`
+	JobTicket     string          `field:"job_ticket"`
+	LocationName  string          `field:"location_name"`
+	WarehouseName string          `field:"warehouse_name"`
+	CustomerName  string          `field:"customer_name"`
+	RemainingQty  int             `field:"remaining_quantity"`
 }
 
 type TransactionRep struct {
-	StockID      string    `field:"stock_id"`
-	MaterialType string    `field:"material_type"`
-	Qty          int       `field:"quantity"`
-	UnitCost     float64   `field:"unit_cost"`
-	Cost         float64   `field:"cost"`
-	UpdatedAt    time.Time `field:"updated_at"`
-	TotalValue   float64   `field:"total_value"`
+	CustomerName string          `field:"customer_name"`
+	StockID      string          `field:"stock_id"`
+	MaterialType string          `field:"material_type"`
+	Location     string          `field:"location"`
+	Qty          int             `field:"quantity"`
+	UnitCost     decimal.Decimal `field:"unit_cost"`
+	Cost         decimal.Decimal `field:"cost"`
+	UpdatedAt    time.Time       `field:"updated_at"`
+	TotalValue   decimal.Decimal `field:"total_value"`
 }
 
 type SearchFilter struct {
 	stockID      string
 	customerID   int
+	customerName string
 	locationID   int
 	materialType string
-	dateFrom     string
-	dateTo       string
-	dateAsOf     string
+	owner        string
+	jobTicket    string
+	status       string
+	dateFrom     time.Time // the dates are whole days, zero when not set
+	dateTo       time.Time
+	dateAsOf     time.Time
+	unit         string // packaging unit of the quantities, the base units when empty
 }
 
+type ColumnType int
+
+const (
+	TextColumn     ColumnType = iota
+	NumberColumn              // numbers without a total, e.g. IDs or limits
+	QuantityColumn            // quantities summed in the totals row
+	PriceColumn               // currency without a total, e.g. unit costs
+	AmountColumn              // currency amounts without a total, e.g. balances
+	ValueColumn               // currency summed in the totals row
+	DateColumn
+)
+
 type Reporter interface {
 	getReportList() [][]string
+	getColumnTypes() []ColumnType
+	getReportHeader() ReportHeader
 	showReport()
 }
 
@@ -130,59 +153,32 @@ func getReport(r Reporter) {
 	r.showReport()
 }
 
-func getReportTable(list [][]string) fyne.Widget {
-	reportTable := widget.NewTable(
-		func() (int, int) {
-			return len(list), len(list[0])
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("Transactions")
-		},
-		func(i widget.TableCellID, o fyne.CanvasObject) {
-			o.(*widget.Label).SetText(list[i.Row][i.Col])
-		})
-
-	for col := 0; col < len(list[0]); col++ {
-		reportTable.SetColumnWidth(col, float32(150))
-	}
-
-	return reportTable
-}
-
-func downloadReport(window fyne.Window, list [][]string, fileName string) {
-	file, err := os.Create("../reports/ " + fileName + ".csv")
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	writer.WriteAll(list)
-
-	dialog := dialog.NewInformation("Success", "File has been saved to the reports folder", window)
-	dialog.Show()
-}
-
 func (i InventoryReport) getReportList() [][]string {
-	rows, err := i.db.Query(`SELECT m.material_id, m.stock_id, l.name, m.description,
-							m.notes, m.quantity, m.min_required_quantity, m.max_required_quantity,
-							m.updated_at, c.name, m.material_type,
+	rows, err := i.db.Query(`SELECT m.material_id, m.stock_id, l.name, COALESCE(it.description, ''),
+							m.notes, m.quantity, COALESCE(it.min_required_quantity, 0),
+							COALESCE(it.max_required_quantity, 0),
+							m.updated_at, c.name, it.material_type,
 								CASE
-									WHEN m.is_active THEN 'Yes'
+									WHEN it.is_active THEN 'Yes'
 									ELSE 'No'
 								END AS is_active,
-							m.cost, m.owner
+							m.cost, m.owner,
+							COALESCE(u.unit, it.unit), COALESCE(u.factor, 1)
 							FROM materials m
+							JOIN items it ON it.item_id = m.item_id
+							LEFT JOIN item_units u ON u.item_id = it.item_id AND u.unit = $6
 							LEFT JOIN locations l ON m.location_id = l.location_id
 							LEFT JOIN customers c ON c.customer_id = m.customer_id
 							WHERE 
+								m.quantity > 0 AND
 								($1 = '' OR m.stock_id = $1) AND
 								($2 = 0 OR c.customer_id = $2) AND
-								($3 = 0 OR l.location_id = $3)
+								($3 = 0 OR l.location_id = $3) AND
+								($4::timestamp IS NULL OR m.updated_at >= $4) AND
+								($5::timestamp IS NULL OR m.updated_at < $5)
 							ORDER BY m.updated_at ASC;`,
-		i.invFilter.stockID, i.invFilter.customerID, i.invFilter.locationID)
+		i.invFilter.stockID, i.invFilter.customerID, i.invFilter.locationID,
+		toNullTime(i.invFilter.dateFrom), toNullTime(getDayAfter(i.invFilter.dateTo)), i.invFilter.unit)
 	if err != nil {
 		fmt.Printf("Error getMaterialsTable1: %e", err)
 	}
@@ -190,11 +186,16 @@ func (i InventoryReport) getReportList() [][]string {
 	invList := [][]string{
 		{
 			"Material ID", "Stock ID", "Location", "Material Type",
-			"Description", "Notes", "Quantity", "Min Qty",
+			"Description", "Notes", "Unit", "Quantity", "Min Qty",
 			"Max Qty", "Updated At", "Customer", "Is Active", "Owner",
+			"Daily Usage", "Days of Supply", "Stock-out Date",
+			"Total On Hand", "Reserved", "Available",
 		},
 	}
 
+	forecastsMap, _, _ := fetchUsageForecasts(i.db, i.invFilter.customerID)
+	availabilityMap, _ := fetchAvailabilityMap(i.db, i.invFilter.customerID)
+
 	for rows.Next() {
 		inv := Material{}
 
@@ -216,29 +217,71 @@ func (i InventoryReport) getReportList() [][]string {
 			strconv.Itoa(day) + "/" +
 			strconv.Itoa(year)
 
-		invList = append(invList, []string{
+		row := []string{
 			strconv.Itoa(inv.MaterialID),
 			inv.StockID,
 			inv.LocationName,
 			inv.MaterialType,
 			inv.Description,
 			inv.Notes,
-			strconv.Itoa(inv.Quantity),
-			strconv.Itoa(inv.MinRequiredQty),
-			strconv.Itoa(inv.MaxRequiredQty),
+			inv.Unit,
+			formatQuantityInUnit(inv.Quantity, inv.UnitFactor),
+			formatQuantityInUnit(inv.MinRequiredQty, inv.UnitFactor),
+			formatQuantityInUnit(inv.MaxRequiredQty, inv.UnitFactor),
 			strDate,
 			inv.CustomerName,
 			inv.IsActive,
 			inv.Owner,
-		})
+		}
+
+		if forecast, ok := forecastsMap[getForecastKey(inv.CustomerName, inv.StockID)]; ok {
+			row = append(row, forecast.getForecastColumns()...)
+		} else {
+			row = append(row, "", "", "")
+		}
+
+		// Stock ID totals of the customer across the locations
+		availability := availabilityMap[getForecastKey(inv.CustomerName, inv.StockID)]
+		row = append(row,
+			formatQuantityInUnit(availability.onHand, inv.UnitFactor),
+			formatQuantityInUnit(availability.reserved, inv.UnitFactor),
+			formatQuantityInUnit(availability.getAvailable(), inv.UnitFactor),
+		)
+
+		invList = append(invList, row)
 	}
 
 	return invList
 }
 
-func (i InventoryReport) showReport() {
-	window := i.app.NewWindow("Inventory")
+func (i InventoryReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		NumberColumn, TextColumn, TextColumn, TextColumn,
+		TextColumn, TextColumn, TextColumn, getUnitQuantityColumn(i.invFilter.unit), NumberColumn,
+		NumberColumn, DateColumn, TextColumn, TextColumn, TextColumn,
+		NumberColumn, NumberColumn, DateColumn,
+		NumberColumn, NumberColumn, NumberColumn,
+	}
+}
+
+func (i InventoryReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Inventory List",
+		customerName: i.invFilter.customerName,
+		period:       i.getPeriod(),
+	}
+}
+
+// Materials updated within the dates of the filter, if they are set
+func (i InventoryReport) getPeriod() string {
+	if i.invFilter.dateFrom.IsZero() && i.invFilter.dateTo.IsZero() {
+		return "As of " + formatReportDate(time.Now())
+	}
 
+	return "Updated " + formatPeriod(i.invFilter.dateFrom, i.invFilter.dateTo)
+}
+
+func (i InventoryReport) showReport() {
 	customers, _ := fetchCustomers(i.db)
 	var customersStr []string
 	customersMap := make(map[string]int)
@@ -258,6 +301,10 @@ func (i InventoryReport) showReport() {
 	stockIDInput := widget.NewEntry()
 	customerSelector := widget.NewSelect(customersStr, func(s string) {})
 	locationSelector := widget.NewSelect(locationsStr, func(s string) {})
+	dateFromEntry := newDateEntry(i.window)
+	dateToEntry := newDateEntry(i.window)
+	rangeSelector := newQuickRangeSelector(dateFromEntry, dateToEntry)
+	unitSelector := newReportUnitSelector(i.db)
 
 	// Filter Inventory List by options
 	dialog := dialog.NewForm("Filter Options", "Show", "",
@@ -265,62 +312,92 @@ func (i InventoryReport) showReport() {
 			widget.NewFormItem("Stock ID", stockIDInput),
 			widget.NewFormItem("Customer", customerSelector),
 			widget.NewFormItem("Location", locationSelector),
+			widget.NewFormItem("Updated", rangeSelector),
+			widget.NewFormItem("Date From", dateFromEntry),
+			widget.NewFormItem("Date To", dateToEntry),
+			widget.NewFormItem("Unit", unitSelector),
 		}, func(confirm bool) {
 			if confirm {
+				from, to, err := getDateRange(dateFromEntry, dateToEntry)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), i.window)
+					return
+				}
+
 				i.invFilter = SearchFilter{
-					stockID:    stockIDInput.Text,
-					customerID: customersMap[customerSelector.Selected],
-					locationID: locationsMap[locationSelector.Selected],
+					stockID:      stockIDInput.Text,
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+					locationID:   locationsMap[locationSelector.Selected],
+					dateFrom:     from,
+					dateTo:       to,
+					unit:         strings.TrimSpace(unitSelector.Text),
 				}
 
 				invList := i.getReportList()
-				inventoryTable := getReportTable(invList)
-				window.SetContent(inventoryTable)
-				window.Resize(fyne.NewSize(1600, 700))
-				window.Show()
+				showReportWindow(i.app, "Inventory", i, invList, "inventory", fyne.NewSize(1600, 700))
 			}
 		}, i.window)
 
-	dialog.Resize(fyne.NewSize(600, 200))
+	dialog.Resize(fyne.NewSize(600, 400))
 	dialog.Show()
 
 }
 
 func (t TransactionReport) getReportList() [][]string {
-	rows, err := t.db.Query(`SELECT tl.stock_id, m.material_type, tl.quantity_change as "quantity",
+	rows, err := t.db.Query(`SELECT COALESCE(c.name, ''), tl.stock_id, COALESCE(tl.material_type::TEXT, ''),
+								CASE
+									WHEN tl.from_location_id IS NOT NULL
+										THEN COALESCE(fl.name, '') || ' -> ' || COALESCE(tol.name, '')
+									ELSE COALESCE(l.name, '')
+								END AS "location",
+								tl.quantity_change as "quantity",
 								tl.cost as "unit_cost",
 								(tl.quantity_change * tl.cost) as "cost",
-								tl.updated_at
+								tl.updated_at,
+								COALESCE(u.unit, i.unit, $5),
+								COALESCE(u.factor, 1)
 							 FROM transactions_log tl
-							 LEFT JOIN materials m ON m.material_id = tl.material_id
-							 LEFT JOIN customers c ON m.customer_id = c.customer_id
+							 LEFT JOIN items i ON i.customer_id = tl.customer_id AND i.stock_id = tl.stock_id
+							 LEFT JOIN item_units u ON u.item_id = i.item_id AND u.unit = $6
+							 LEFT JOIN customers c ON c.customer_id = tl.customer_id
+							 LEFT JOIN locations l ON l.location_id = tl.location_id
+							 LEFT JOIN locations fl ON fl.location_id = tl.from_location_id
+							 LEFT JOIN locations tol ON tol.location_id = tl.to_location_id
 							 WHERE 
-								($1 = 0 OR m.customer_id = $1) AND
-								($2 = '' OR m.material_type::TEXT = $2) AND
-								tl.updated_at::TEXT >= $3 AND
-								tl.updated_at::TEXT <= $4
+								($1 = 0 OR tl.customer_id = $1) AND
+								($2 = '' OR tl.material_type::TEXT = $2) AND
+								tl.updated_at >= $3 AND
+								tl.updated_at < $4
 							 ORDER BY transaction_id;`,
-		t.trxFilter.customerID, t.trxFilter.materialType, t.trxFilter.dateFrom, t.trxFilter.dateTo)
+		t.trxFilter.customerID, t.trxFilter.materialType, t.trxFilter.dateFrom, getDayAfter(t.trxFilter.dateTo),
+		defaultItemUnit, t.trxFilter.unit)
 	if err != nil {
 		fmt.Printf("Error getTransactionsTable1: %e", err)
 	}
 
 	trxList := [][]string{
 		{
-			"Stock ID", "Material Type", "Quantity (+/-)", "Unit Price, USD", "Price, USD", "Accepted Date",
+			"Customer", "Stock ID", "Material Type", "Location", "Unit", "Quantity (+/-)", "Unit Price, USD", "Price, USD", "Accepted Date",
 		},
 	}
 
 	for rows.Next() {
 		trx := TransactionRep{}
+		var unit string
+		var factor int
 
 		err := rows.Scan(
+			&trx.CustomerName,
 			&trx.StockID,
 			&trx.MaterialType,
+			&trx.Location,
 			&trx.Qty,
 			&trx.UnitCost,
 			&trx.Cost,
 			&trx.UpdatedAt,
+			&unit,
+			&factor,
 		)
 
 		if err != nil {
@@ -332,13 +409,16 @@ func (t TransactionReport) getReportList() [][]string {
 			strconv.Itoa(day) + "/" +
 			strconv.Itoa(year)
 
-		unitCost := accLib.FormatMoney(trx.UnitCost)
-		cost := accLib.FormatMoney(trx.Cost)
+		unitCost := formatUnitCost(trx.UnitCost)
+		cost := formatMoney(trx.Cost)
 
 		trxList = append(trxList, []string{
+			trx.CustomerName,
 			trx.StockID,
 			trx.MaterialType,
-			strconv.Itoa(trx.Qty),
+			trx.Location,
+			unit,
+			formatQuantityInUnit(trx.Qty, factor),
 			unitCost,
 			cost,
 			strDate,
@@ -348,6 +428,21 @@ func (t TransactionReport) getReportList() [][]string {
 	return trxList
 }
 
+func (t TransactionReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, TextColumn, TextColumn, TextColumn, getUnitQuantityColumn(t.trxFilter.unit),
+		PriceColumn, ValueColumn, DateColumn,
+	}
+}
+
+func (t TransactionReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Transactions Report",
+		customerName: t.trxFilter.customerName,
+		period:       formatPeriod(t.trxFilter.dateFrom, t.trxFilter.dateTo),
+	}
+}
+
 func (t TransactionReport) showReport() {
 	customers, _ := fetchCustomers(t.db)
 	var customersStr []string
@@ -359,90 +454,71 @@ func (t TransactionReport) showReport() {
 
 	customerSelector := widget.NewSelect(customersStr, func(s string) {})
 	typeSelector := widget.NewSelect([]string{"Carrier", "Card", "Envelope", "Insert", "Consumables"}, func(s string) {})
-	dateFromEntry := widget.NewEntry()
-	dateFromEntry.SetText(
-		padStart(strconv.Itoa(int(time.Now().Month())), 2, '0') + "/" +
-			"01" + "/" +
-			strconv.Itoa(time.Now().Year()),
-	)
-	dateToEntry := widget.NewEntry()
-	dateToEntry.SetText(
-		padStart(strconv.Itoa(int(time.Now().Month())), 2, '0') + "/" +
-			padStart(strconv.Itoa(time.Now().Day()), 2, '0') + "/" +
-			strconv.Itoa(time.Now().Year()),
-	)
+	dateFromEntry := newDateEntry(t.window)
+	dateToEntry := newDateEntry(t.window)
+	rangeSelector := newQuickRangeSelector(dateFromEntry, dateToEntry)
+	rangeSelector.SetSelected("This month")
+	unitSelector := newReportUnitSelector(t.db)
 
 	// Filter Inventory List by options
 	dialog := dialog.NewForm("Filter Options", "Show", "",
 		[]*widget.FormItem{
 			widget.NewFormItem("Customer", customerSelector),
 			widget.NewFormItem("Material Type", typeSelector),
-			widget.NewFormItem("Date From (MM/DD/YYYY)", dateFromEntry),
-			widget.NewFormItem("Date To (MM/DD/YYYY)", dateToEntry),
+			widget.NewFormItem("Period", rangeSelector),
+			widget.NewFormItem("Date From", dateFromEntry),
+			widget.NewFormItem("Date To", dateToEntry),
+			widget.NewFormItem("Unit", unitSelector),
 		}, func(confirm bool) {
 			if confirm {
-				parsedDateFrom := strings.Split(dateFromEntry.Text, "/")
-				monthFrom := parsedDateFrom[0]
-				dayFrom := parsedDateFrom[1]
-				yearFrom := parsedDateFrom[2]
-
-				parsedDateTo := strings.Split(dateToEntry.Text, "/")
-				monthTo := parsedDateTo[0]
-				dayTo := parsedDateTo[1]
-				yearTo := parsedDateTo[2]
+				from, to, err := getDateRange(dateFromEntry, dateToEntry)
+				if err == nil && (from.IsZero() || to.IsZero()) {
+					err = errors.New("Choose both Date From and Date To")
+				}
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), t.window)
+					return
+				}
 
 				t.trxFilter = SearchFilter{
 					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
 					materialType: typeSelector.Selected,
-					dateFrom:     yearFrom + "-" + monthFrom + "-" + dayFrom + " 00:00:00.000000",
-					dateTo:       yearTo + "-" + monthTo + "-" + dayTo + " 23:59:59.999999",
+					dateFrom:     from,
+					dateTo:       to,
+					unit:         strings.TrimSpace(unitSelector.Text),
 				}
 
-				window := t.app.NewWindow("Transactions")
-
 				trxList := t.getReportList()
-				transactionsTable := getReportTable(trxList)
-
-				fileMenu := fyne.NewMenu("File", fyne.NewMenuItem("Save as .csv", func() {
-					fileNameEntry := widget.NewEntry()
-					dialog := dialog.NewForm("Save the File", "Save", "Cancel", []*widget.FormItem{
-						widget.NewFormItem("File name", fileNameEntry),
-					}, func(confirm bool) {
-						if confirm {
-							downloadReport(window, trxList, fileNameEntry.Text)
-						}
-					}, window)
-					dialog.Resize(fyne.NewSize(400, 50))
-					dialog.Show()
-				}))
-
-				window.SetMainMenu(fyne.NewMainMenu(fileMenu))
-				window.SetContent(transactionsTable)
-				window.Resize(fyne.NewSize(1000, 700))
-				window.Show()
+				showReportWindow(t.app, "Transactions", t, trxList, "transactions", fyne.NewSize(1000, 700))
 
 			}
 		}, t.window)
 
-	dialog.Resize(fyne.NewSize(600, 200))
+	dialog.Resize(fyne.NewSize(600, 250))
 	dialog.Show()
 }
 
 func (b BalanceReport) getReportList() [][]string {
 	rows, err := b.db.Query(`
-	SELECT m.stock_id,
-		   m.material_type,
-		   SUM(tl.quantity_change) AS "quantity",
-		   SUM(tl.quantity_change * tl.cost) AS "total_value"
-	FROM transactions_log tl
-	LEFT JOIN materials m ON m.material_id = tl.material_id
+	SELECT COALESCE(c.name, ''),
+		   b.stock_id,
+		   COALESCE(b.material_type::TEXT, ''),
+		   COALESCE(u.unit, i.unit, $5),
+		   SUM(b.quantity)::float / COALESCE(u.factor, 1) AS "quantity",
+		   SUM(b.value) AS "total_value"
+	FROM material_balances($3) b
+	LEFT JOIN customers c ON c.customer_id = b.customer_id
+	LEFT JOIN items i ON i.customer_id = b.customer_id AND i.stock_id = b.stock_id
+	LEFT JOIN item_units u ON u.item_id = i.item_id AND u.unit = $4
 	WHERE
-		($1 = 0 OR m.customer_id = $1) AND
-		($2 = '' OR m.material_type::TEXT = $2) AND
-		tl.updated_at::TEXT <= $3
-	GROUP BY m.stock_id, m.material_type
+		($1 = 0 OR b.customer_id = $1) AND
+		($2 = '' OR b.material_type::TEXT = $2)
+	GROUP BY 1, 2, 3, 4, u.factor
+	ORDER BY 1, 2
 `,
-		b.blcFilter.customerID, b.blcFilter.materialType, b.blcFilter.dateAsOf,
+		b.blcFilter.customerID, b.blcFilter.materialType, getDayAfter(b.blcFilter.dateAsOf),
+		b.blcFilter.unit, defaultItemUnit,
 	)
 	if err != nil {
 		fmt.Printf("Error getBalanceTable1: %e", err)
@@ -450,25 +526,29 @@ func (b BalanceReport) getReportList() [][]string {
 
 	blcList := [][]string{
 		{
-			"Stock ID", "Material Type", "Quantity", "Total Value, USD",
+			"Customer", "Stock ID", "Material Type", "Unit", "Quantity", "Total Value, USD",
 		},
 	}
 
 	for rows.Next() {
 		balance := TransactionRep{}
+		var unit string
+		var quantity float64
 
-		err := rows.Scan(&balance.StockID, &balance.MaterialType, &balance.Qty, &balance.TotalValue)
+		err := rows.Scan(&balance.CustomerName, &balance.StockID, &balance.MaterialType, &unit, &quantity, &balance.TotalValue)
 
 		if err != nil {
 			log.Printf("Error getBalanceTable2: %e", err)
 		}
 
-		totalValue := accLib.FormatMoney(balance.TotalValue)
+		totalValue := formatMoney(balance.TotalValue)
 
 		blcList = append(blcList, []string{
+			balance.CustomerName,
 			balance.StockID,
 			balance.MaterialType,
-			strconv.Itoa(balance.Qty),
+			unit,
+			formatUnitQuantity(quantity),
 			totalValue,
 		})
 	}
@@ -477,6 +557,20 @@ func (b BalanceReport) getReportList() [][]string {
 
 }
 
+func (b BalanceReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, TextColumn, TextColumn, getUnitQuantityColumn(b.blcFilter.unit), ValueColumn,
+	}
+}
+
+func (b BalanceReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Balance Report",
+		customerName: b.blcFilter.customerName,
+		period:       "As of " + formatReportDate(b.blcFilter.dateAsOf),
+	}
+}
+
 func (b BalanceReport) showReport() {
 	customers, _ := fetchCustomers(b.db)
 	var customersStr []string
@@ -488,56 +582,38 @@ func (b BalanceReport) showReport() {
 
 	customerSelector := widget.NewSelect(customersStr, func(s string) {})
 	typeSelector := widget.NewSelect([]string{"Carrier", "Card", "Envelope", "Insert", "Consumables"}, func(s string) {})
-	dateAsOf := widget.NewEntry()
-	dateAsOf.SetText(
-		padStart(strconv.Itoa(int(time.Now().Month())), 2, 0) + "/" +
-			padStart(strconv.Itoa(time.Now().Day()), 2, '0') + "/" +
-			strconv.Itoa(time.Now().Year()),
-	)
+	dateAsOf := newDateEntry(b.window)
+	dateAsOf.SetDate(time.Now())
+	unitSelector := newReportUnitSelector(b.db)
 
 	// Filter Inventory List by options
 	dialog := dialog.NewForm("Filter Options", "Show", "",
 		[]*widget.FormItem{
 			widget.NewFormItem("Customer", customerSelector),
 			widget.NewFormItem("Material Type", typeSelector),
-			widget.NewFormItem("Date As of (MM/DD/YYYY)", dateAsOf),
+			widget.NewFormItem("Date As of", dateAsOf),
+			widget.NewFormItem("Unit", unitSelector),
 		}, func(confirm bool) {
 			if confirm {
-				parsedDate := strings.Split(dateAsOf.Text, "/")
-				month := parsedDate[0]
-				day := parsedDate[1]
-				year := parsedDate[2]
+				asOf, err := dateAsOf.GetDate()
+				if err != nil || asOf.IsZero() {
+					dialog.ShowInformation("Error", "The date must be in the "+getDateHint()+" format", b.window)
+					return
+				}
 
 				b.blcFilter = SearchFilter{
 					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
 					materialType: typeSelector.Selected,
-					dateAsOf:     year + "-" + month + "-" + day + " 23:59:59.999999",
+					dateAsOf:     asOf,
+					unit:         strings.TrimSpace(unitSelector.Text),
 				}
 
-				window := b.app.NewWindow("Transactions Balance")
 				blcList := b.getReportList()
-				balanceTable := getReportTable(blcList)
-
-				fileMenu := fyne.NewMenu("File", fyne.NewMenuItem("Save as .csv", func() {
-					fileNameEntry := widget.NewEntry()
-					dialog := dialog.NewForm("Save the File", "Save", "Cancel", []*widget.FormItem{
-						widget.NewFormItem("File name", fileNameEntry),
-					}, func(confirm bool) {
-						if confirm {
-							downloadReport(window, blcList, fileNameEntry.Text)
-						}
-					}, window)
-					dialog.Resize(fyne.NewSize(400, 50))
-					dialog.Show()
-				}))
-
-				window.SetMainMenu(fyne.NewMainMenu(fileMenu))
-				window.SetContent(balanceTable)
-				window.Resize(fyne.NewSize(650, 400))
-				window.Show()
+				showReportWindow(b.app, "Transactions Balance", b, blcList, "balance", fyne.NewSize(650, 400))
 			}
 		}, b.window)
 
-	dialog.Resize(fyne.NewSize(500, 200))
+	dialog.Resize(fyne.NewSize(500, 250))
 	dialog.Show()
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Item catalog of the customers with the forms to add and change the items
+func showItems(app fyne.App, db *sql.DB) {
+	window := app.NewWindow("Item Catalog")
+
+	customers, _ := fetchCustomers(db)
+	customersStr := []string{"All"}
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+
+	var refresh func()
+	refresh = func() {
+		items, err := fetchItems(db, customersMap[customerSelector.Selected])
+		if err != nil {
+			dialog.ShowInformation("Error", err.Error(), window)
+		}
+
+		itemWidgets := []fyne.CanvasObject{}
+		for _, item := range items {
+			i := item
+
+			details := i.materialType + ", " + i.unit + ", " + i.defaultOwner + " owned"
+			if i.description != "" {
+				details += ": " + i.description
+			}
+			if i.minQty > 0 || i.maxQty > 0 {
+				details += ", min " + strconv.Itoa(i.minQty) + ", max " + strconv.Itoa(i.maxQty)
+			}
+			if !i.isActive {
+				details += ", not allowed for use"
+			}
+
+			stockLabel := widget.NewLabel(i.customerName + " / " + i.stockID)
+			stockLabel.TextStyle.Bold = true
+
+			itemWidgets = append(itemWidgets,
+				container.NewBorder(nil, nil, stockLabel,
+					container.NewHBox(
+						widget.NewButton("Units", func() { editItemUnits(window, db, i) }),
+						widget.NewButton("Edit", func() { editItem(window, db, i, func(int) { refresh() }) }),
+					),
+					widget.NewLabel(details),
+				),
+				widget.NewSeparator(),
+			)
+		}
+
+		toolbar := container.New(layout.NewGridLayoutWithColumns(3),
+			widget.NewButton("New Item", func() {
+				editItem(window, db, Item{customerName: customerSelector.Selected}, func(int) { refresh() })
+			}),
+			customerSelector,
+			widget.NewButton("Refresh", refresh),
+		)
+
+		window.SetContent(container.NewBorder(toolbar, nil, nil, nil,
+			container.NewVScroll(container.NewVBox(itemWidgets...))))
+	}
+
+	customerSelector.SetSelected("All")
+	customerSelector.OnChanged = func(string) { refresh() }
+
+	refresh()
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+}
+
+// The form of a new or an existing item, onSaved gets the ID of the saved item
+func editItem(window fyne.Window, db *sql.DB, i Item, onSaved func(int)) {
+	customers, _ := fetchCustomers(db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	customerSelector.SetSelected(i.customerName)
+	stockIDInput := widget.NewEntry()
+	stockIDInput.SetText(i.stockID)
+	// The stock rows and the history refer to the stock ID of the customer
+	if i.id != 0 {
+		customerSelector.Disable()
+		stockIDInput.Disable()
+	}
+
+	typeSelector := widget.NewSelect(materialTypes, func(s string) {})
+	typeSelector.SetSelected(i.materialType)
+	descriptionInput := widget.NewEntry()
+	descriptionInput.SetText(i.description)
+	unitInput := widget.NewEntry()
+	unitInput.SetPlaceHolder(defaultItemUnit)
+	unitInput.SetText(i.unit)
+	minQtyInput := widget.NewEntry()
+	maxQtyInput := widget.NewEntry()
+	if i.minQty > 0 {
+		minQtyInput.SetText(strconv.Itoa(i.minQty))
+	}
+	if i.maxQty > 0 {
+		maxQtyInput.SetText(strconv.Itoa(i.maxQty))
+	}
+	ownerSelector := widget.NewSelect(owners, func(s string) {})
+	if i.defaultOwner == "" {
+		i.defaultOwner = "Customer"
+	}
+	ownerSelector.SetSelected(i.defaultOwner)
+	isActiveChkBox := widget.NewCheck("", func(b bool) {})
+	isActiveChkBox.SetChecked(i.id == 0 || i.isActive)
+
+	dialog.ShowForm("Item", "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Stock ID *", stockIDInput),
+			widget.NewFormItem("Type *", typeSelector),
+			widget.NewFormItem("Description", descriptionInput),
+			widget.NewFormItem("Unit", unitInput),
+			widget.NewFormItem("Min Quantity", minQtyInput),
+			widget.NewFormItem("Max Quantity", maxQtyInput),
+			widget.NewFormItem("Default Owner", ownerSelector),
+			widget.NewFormItem("Allow for use", isActiveChkBox),
+		}, func(confirm bool) {
+			if confirm {
+				minQty, errMin := parseOptionalQuantity(minQtyInput.Text)
+				maxQty, errMax := parseOptionalQuantity(maxQtyInput.Text)
+				if errMin != nil || errMax != nil {
+					dialog.ShowInformation("Error", "The min and max quantities must be whole numbers", window)
+					return
+				}
+
+				i.customerID = customersMap[customerSelector.Selected]
+				i.customerName = customerSelector.Selected
+				i.stockID = strings.TrimSpace(stockIDInput.Text)
+				i.materialType = typeSelector.Selected
+				i.description = strings.TrimSpace(descriptionInput.Text)
+				i.unit = strings.TrimSpace(unitInput.Text)
+				i.minQty = minQty
+				i.maxQty = maxQty
+				i.defaultOwner = ownerSelector.Selected
+				i.isActive = isActiveChkBox.Checked
+
+				id, err := saveItem(db, i)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+				onSaved(id)
+			}
+		}, window)
+}
+
+// Whole number of an optional quantity input, 0 when it is empty
+func parseOptionalQuantity(text string) (int, error) {
+	text = strings.ReplaceAll(strings.TrimSpace(text), ",", "")
+	if text == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(text)
+}
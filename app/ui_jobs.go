@@ -0,0 +1,152 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Jobs with the forms to add, change, close and reopen them
+func showJobs(app fyne.App, db *sql.DB) {
+	window := app.NewWindow("Jobs")
+
+	statusSelector := widget.NewSelect(append([]string{"All"}, jobStatuses...), func(s string) {})
+
+	var refresh func()
+	refresh = func() {
+		status := statusSelector.Selected
+		if status == "All" {
+			status = ""
+		}
+
+		jobs, err := fetchJobs(db, 0, status)
+		if err != nil {
+			dialog.ShowInformation("Error", err.Error(), window)
+		}
+
+		jobWidgets := []fyne.CanvasObject{}
+		for _, job := range jobs {
+			j := job
+
+			details := j.customerName
+			if j.description != "" {
+				details += ": " + j.description
+			}
+			if j.dueDate.Valid {
+				details += ", due " + formatReportDate(j.dueDate.Time)
+			}
+			if j.closedAt.Valid {
+				details += ", closed " + formatReportDate(j.closedAt.Time)
+			}
+
+			ticketLabel := widget.NewLabel(j.jobTicket + " (" + j.status + ")")
+			ticketLabel.TextStyle.Bold = true
+
+			var buttons *fyne.Container
+			if j.status == jobOpen {
+				buttons = container.NewHBox(
+					widget.NewButton("Edit", func() { editJob(window, db, j, refresh) }),
+					widget.NewButton("Close Job", func() {
+						dialog.ShowConfirm("Close Job", "Close the job "+j.jobTicket+
+							"? Its open reservations are released and no material can be used for it.",
+							func(confirm bool) {
+								if confirm {
+									if err := closeJob(db, j); err != nil {
+										dialog.ShowInformation("Error", err.Error(), window)
+									}
+									refresh()
+								}
+							}, window)
+					}),
+				)
+			} else {
+				buttons = container.NewHBox(
+					widget.NewButton("Reopen", func() {
+						if err := reopenJob(db, j); err != nil {
+							dialog.ShowInformation("Error", err.Error(), window)
+						}
+						refresh()
+					}),
+				)
+			}
+
+			jobWidgets = append(jobWidgets,
+				container.NewBorder(nil, nil, ticketLabel, buttons, widget.NewLabel(details)),
+				widget.NewSeparator(),
+			)
+		}
+
+		toolbar := container.New(layout.NewGridLayoutWithColumns(3),
+			widget.NewButton("New Job", func() { editJob(window, db, Job{}, refresh) }),
+			statusSelector,
+			widget.NewButton("Refresh", refresh),
+		)
+
+		window.SetContent(container.NewBorder(toolbar, nil, nil, nil,
+			container.NewVScroll(container.NewVBox(jobWidgets...))))
+	}
+
+	statusSelector.SetSelected(jobOpen)
+	statusSelector.OnChanged = func(string) { refresh() }
+
+	refresh()
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+}
+
+func editJob(window fyne.Window, db *sql.DB, j Job, onSaved func()) {
+	customers, _ := fetchCustomers(db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	ticketInput := widget.NewEntry()
+	ticketInput.SetText(j.jobTicket)
+	// The postings refer to the ticket
+	if j.id != 0 {
+		ticketInput.Disable()
+	}
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	customerSelector.SetSelected(j.customerName)
+	descriptionInput := widget.NewEntry()
+	descriptionInput.SetText(j.description)
+	dueDateInput := newDateEntry(window)
+	if j.dueDate.Valid {
+		dueDateInput.SetDate(j.dueDate.Time)
+	}
+
+	dialog.ShowForm("Job", "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Job Ticket *", ticketInput),
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Description", descriptionInput),
+			widget.NewFormItem("Due Date", dueDateInput),
+		}, func(confirm bool) {
+			if confirm {
+				dueDate, err := dueDateInput.GetDate()
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+
+				j.jobTicket = strings.TrimSpace(ticketInput.Text)
+				j.customerID = customersMap[customerSelector.Selected]
+				j.description = strings.TrimSpace(descriptionInput.Text)
+				j.dueDate = toNullTime(dueDate)
+
+				if err := saveJob(db, j); err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+				onSaved()
+			}
+		}, window)
+}
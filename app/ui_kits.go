@@ -0,0 +1,290 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Kits of all customers with the forms to add, change and run them
+func showKits(app fyne.App, db *sql.DB) {
+	window := app.NewWindow("Kits")
+
+	var refresh func()
+	refresh = func() {
+		kits, err := fetchKits(db, 0)
+		if err != nil {
+			dialog.ShowInformation("Error", err.Error(), window)
+		}
+
+		kitWidgets := []fyne.CanvasObject{}
+		for _, kit := range kits {
+			k := kit
+
+			var components []string
+			for _, component := range k.components {
+				components = append(components, strconv.Itoa(component.quantityPerUnit)+" x "+component.stockID)
+			}
+
+			nameLabel := widget.NewLabel(k.customerName + " / " + k.name)
+			nameLabel.TextStyle.Bold = true
+			componentsLabel := widget.NewLabel(strings.Join(components, ", "))
+			componentsLabel.Wrapping = fyne.TextWrapWord
+
+			kitWidgets = append(kitWidgets,
+				container.NewBorder(nil, nil, nameLabel,
+					container.NewHBox(
+						widget.NewButton("Run Job", func() { runJob(window, db, &k) }),
+						widget.NewButton("Edit", func() { editKit(window, db, k, refresh) }),
+						widget.NewButton("Delete", func() {
+							dialog.ShowConfirm("Delete Kit", "Delete the kit \""+k.name+"\"?", func(confirm bool) {
+								if confirm {
+									if err := deleteKit(db, k.id); err != nil {
+										dialog.ShowInformation("Error", err.Error(), window)
+									}
+									refresh()
+								}
+							}, window)
+						}),
+					),
+					componentsLabel,
+				),
+				widget.NewSeparator(),
+			)
+		}
+
+		toolbar := container.New(layout.NewGridLayoutWithColumns(3),
+			widget.NewButton("New Kit", func() { editKit(window, db, Kit{}, refresh) }),
+			widget.NewButton("Run Job", func() { runJob(window, db, nil) }),
+			widget.NewButton("Refresh", refresh),
+		)
+
+		window.SetContent(container.NewBorder(toolbar, nil, nil, nil,
+			container.NewVScroll(container.NewVBox(kitWidgets...))))
+	}
+
+	refresh()
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+}
+
+// Component rows of the kit form
+type componentRow struct {
+	stockInput    *widget.SelectEntry
+	quantityInput *widget.Entry
+	content       fyne.CanvasObject
+}
+
+func editKit(window fyne.Window, db *sql.DB, k Kit, onSaved func()) {
+	customers, _ := fetchCustomers(db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	var rows []*componentRow
+	rowsBox := container.NewVBox()
+	var stockIDs []string
+
+	addRow := func(component KitComponent) {
+		row := &componentRow{
+			stockInput:    widget.NewSelectEntry(stockIDs),
+			quantityInput: widget.NewEntry(),
+		}
+		row.stockInput.SetText(component.stockID)
+		row.stockInput.SetPlaceHolder("Stock ID")
+		if component.quantityPerUnit > 0 {
+			row.quantityInput.SetText(strconv.Itoa(component.quantityPerUnit))
+		}
+		row.quantityInput.SetPlaceHolder("Per unit")
+
+		removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+		row.content = container.NewBorder(nil, nil, nil,
+			container.NewHBox(container.NewGridWrap(fyne.NewSize(100, row.quantityInput.MinSize().Height), row.quantityInput), removeButton),
+			row.stockInput)
+		removeButton.OnTapped = func() {
+			for i, r := range rows {
+				if r == row {
+					rows = append(rows[:i], rows[i+1:]...)
+					break
+				}
+			}
+			rowsBox.Remove(row.content)
+		}
+
+		rows = append(rows, row)
+		rowsBox.Add(row.content)
+	}
+
+	customerSelector := widget.NewSelect(customersStr, func(customerName string) {
+		// Offer the stock IDs of the customer for the components
+		stockIDs, _ = fetchCustomerStockIDs(db, customersMap[customerName])
+		for _, row := range rows {
+			row.stockInput.SetOptions(stockIDs)
+		}
+	})
+	if k.customerName != "" {
+		customerSelector.SetSelected(k.customerName)
+	}
+
+	nameInput := widget.NewEntry()
+	nameInput.SetText(k.name)
+	descriptionInput := widget.NewEntry()
+	descriptionInput.SetText(k.description)
+
+	for _, component := range k.components {
+		addRow(component)
+	}
+	if len(k.components) == 0 {
+		addRow(KitComponent{})
+	}
+
+	addButton := widget.NewButtonWithIcon("Add Component", theme.ContentAddIcon(), func() {
+		addRow(KitComponent{})
+	})
+
+	kitDialog := dialog.NewForm("Kit", "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Product *", nameInput),
+			widget.NewFormItem("Description", descriptionInput),
+			widget.NewFormItem("Components *", container.NewVBox(rowsBox, addButton)),
+		}, func(confirm bool) {
+			if confirm {
+				k.customerID = customersMap[customerSelector.Selected]
+				k.customerName = customerSelector.Selected
+				k.name = strings.TrimSpace(nameInput.Text)
+				k.description = strings.TrimSpace(descriptionInput.Text)
+				k.components = nil
+
+				for _, row := range rows {
+					stockID := strings.TrimSpace(row.stockInput.Text)
+					quantity, _ := strconv.Atoi(strings.TrimSpace(row.quantityInput.Text))
+					if stockID == "" && row.quantityInput.Text == "" {
+						continue
+					}
+					k.components = append(k.components, KitComponent{stockID: stockID, quantityPerUnit: quantity})
+				}
+
+				if err := saveKit(db, k); err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+				onSaved()
+			}
+		}, window)
+
+	kitDialog.Resize(fyne.NewSize(600, 500))
+	kitDialog.Show()
+}
+
+// Issue all components of a kit for a job ticket,
+// the kit is chosen in the form when it is nil
+func runJob(myWindow fyne.Window, db *sql.DB, kit *Kit) {
+	kits, err := fetchKits(db, 0)
+	if err != nil {
+		dialog.ShowInformation("Error", err.Error(), myWindow)
+		return
+	}
+	if len(kits) == 0 {
+		dialog.ShowInformation("Run Job", "There are no kits, add one in Kits first", myWindow)
+		return
+	}
+
+	var kitsStr []string
+	kitsMap := make(map[string]Kit)
+	for _, k := range kits {
+		name := k.customerName + " / " + k.name
+		kitsStr = append(kitsStr, name)
+		kitsMap[name] = k
+	}
+
+	jobSelector := widget.NewSelect([]string{}, func(s string) {})
+	piecesInput := widget.NewEntry()
+	notesInput := widget.NewEntry()
+	availabilityLabel := widget.NewLabel("")
+
+	kitSelector := widget.NewSelect(kitsStr, func(s string) {})
+
+	// Required and available quantities of the chosen kit
+	updateAvailability := func() {
+		k, ok := kitsMap[kitSelector.Selected]
+		if !ok {
+			availabilityLabel.SetText("")
+			return
+		}
+		pieces, _ := strconv.Atoi(strings.Replace(piecesInput.Text, ",", "", -1))
+
+		availability, err := getKitAvailability(db, k, pieces)
+		if err != nil {
+			availabilityLabel.SetText(err.Error())
+			return
+		}
+
+		var lines []string
+		for _, a := range availability {
+			status := "OK"
+			if a.available < a.required {
+				status = "SHORT"
+			}
+			lines = append(lines, fmt.Sprintf("%s: required %d, available %d  %s", a.stockID, a.required, a.available, status))
+		}
+		availabilityLabel.SetText(strings.Join(lines, "\n"))
+	}
+	kitSelector.OnChanged = func(string) {
+		// Open jobs of the kit customer
+		jobSelector.ClearSelected()
+		jobSelector.SetOptions(fetchOpenJobTickets(db, kitsMap[kitSelector.Selected].customerID))
+		updateAvailability()
+	}
+	piecesInput.OnChanged = func(string) { updateAvailability() }
+
+	if kit != nil {
+		kitSelector.SetSelected(kit.customerName + " / " + kit.name)
+	}
+
+	jobDialog := dialog.NewForm("Run Job", "Issue", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Kit *", kitSelector),
+			widget.NewFormItem("Job Ticket *", jobSelector),
+			widget.NewFormItem("Pieces *", piecesInput),
+			widget.NewFormItem("Notes", notesInput),
+			widget.NewFormItem("Components", availabilityLabel),
+		}, func(confirm bool) {
+			if confirm {
+				k, ok := kitsMap[kitSelector.Selected]
+				if !ok {
+					dialog.ShowInformation("Error", "Choose a kit", myWindow)
+					return
+				}
+				pieces, _ := strconv.Atoi(strings.Replace(piecesInput.Text, ",", "", -1))
+
+				run, err := runKitJob(db, k, jobSelector.Selected, pieces, notesInput.Text)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
+
+				var lines []string
+				for _, issued := range run.issued {
+					lines = append(lines, strconv.Itoa(issued.required)+" x "+issued.stockID)
+				}
+				dialog.ShowInformation("Success", "Job "+run.jobTicket+": "+strconv.Itoa(run.pieces)+
+					" piece(s) of "+k.name+" have been issued\n"+strings.Join(lines, "\n")+
+					"\nJob cost: "+formatMoney(run.cost), myWindow)
+			}
+		}, myWindow)
+
+	jobDialog.Resize(fyne.NewSize(600, 400))
+	jobDialog.Show()
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Choose a template and a format and save the labels to a file
+func printLabels(window fyne.Window, db *sql.DB, labels []Label, fileName string) {
+	if len(labels) == 0 {
+		dialog.ShowInformation("Labels", "There are no labels to print", window)
+		return
+	}
+
+	templates, err := fetchLabelTemplates(db)
+	if err != nil {
+		dialog.ShowInformation("Error", err.Error(), window)
+		return
+	}
+
+	var templatesStr []string
+	templatesMap := make(map[string]LabelTemplate)
+	for _, template := range templates {
+		templatesStr = append(templatesStr, template.name)
+		templatesMap[template.name] = template
+	}
+
+	templateSelector := widget.NewSelect(templatesStr, func(s string) {})
+	if len(templatesStr) > 0 {
+		templateSelector.SetSelected(templatesStr[0])
+	}
+	formatSelector := widget.NewSelect(labelFormats, func(s string) {})
+	formatSelector.SetSelected(pdfFormat)
+
+	dialog.ShowForm("Print "+strconv.Itoa(len(labels))+" Label(s)", "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Template", templateSelector),
+			widget.NewFormItem("Format", formatSelector),
+		}, func(confirm bool) {
+			if confirm {
+				template, ok := templatesMap[templateSelector.Selected]
+				if !ok {
+					dialog.ShowInformation("Error", "Choose a label template", window)
+					return
+				}
+				saveLabels(window, labels, template, fileName, formatSelector.Selected)
+			}
+		}, window)
+}
+
+func saveLabels(window fyne.Window, labels []Label, template LabelTemplate, fileName string, format string) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			log.Println("Error saveLabels1: ", err)
+			dialog.ShowError(err, window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := writeLabels(writer, labels, template, format); err != nil {
+			log.Println("Error saveLabels2: ", err)
+			dialog.ShowError(err, window)
+			return
+		}
+
+		dialog.ShowInformation("Success", "Labels have been saved to "+writer.URI().Path(), window)
+	}, window)
+
+	saveDialog.SetFileName(fileName + "." + format)
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{"." + format}))
+	saveDialog.Resize(fyne.NewSize(800, 600))
+	saveDialog.Show()
+}
+
+// List of the label templates with the forms to add and change them
+func showLabelTemplates(window fyne.Window, db *sql.DB) {
+	templates, err := fetchLabelTemplates(db)
+	if err != nil {
+		dialog.ShowInformation("Error", err.Error(), window)
+		return
+	}
+
+	var templatesDialog dialog.Dialog
+	items := []fyne.CanvasObject{}
+
+	for _, template := range templates {
+		t := template
+		items = append(items, container.NewBorder(nil, nil, nil,
+			widget.NewButton("Edit", func() {
+				templatesDialog.Hide()
+				editLabelTemplate(window, db, t)
+			}),
+			widget.NewLabel(t.name+": "+formatMM(t.widthMM)+" x "+formatMM(t.heightMM)+" mm, "+t.barcodeType),
+		))
+	}
+
+	newButton := widget.NewButton("New Template", func() {
+		templatesDialog.Hide()
+		editLabelTemplate(window, db, LabelTemplate{barcodeType: code128Barcode, fontSize: 12})
+	})
+
+	content := container.NewBorder(nil, newButton, nil, nil,
+		container.NewVScroll(container.NewVBox(items...)))
+
+	templatesDialog = dialog.NewCustom("Label Templates", "Close", content, window)
+	templatesDialog.Resize(fyne.NewSize(600, 400))
+	templatesDialog.Show()
+}
+
+func editLabelTemplate(window fyne.Window, db *sql.DB, t LabelTemplate) {
+	nameInput := widget.NewEntry()
+	nameInput.SetText(t.name)
+	widthInput := widget.NewEntry()
+	widthInput.SetText(formatMM(t.widthMM))
+	heightInput := widget.NewEntry()
+	heightInput.SetText(formatMM(t.heightMM))
+	typeSelector := widget.NewSelect(barcodeTypes, func(s string) {})
+	typeSelector.SetSelected(t.barcodeType)
+	fontSizeInput := widget.NewEntry()
+	fontSizeInput.SetText(formatMM(t.fontSize))
+
+	dialog.ShowForm("Label Template", "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Name *", nameInput),
+			widget.NewFormItem("Width, mm *", widthInput),
+			widget.NewFormItem("Height, mm *", heightInput),
+			widget.NewFormItem("Barcode *", typeSelector),
+			widget.NewFormItem("Font size, pt *", fontSizeInput),
+		}, func(confirm bool) {
+			if confirm {
+				width, errWidth := strconv.ParseFloat(strings.TrimSpace(widthInput.Text), 64)
+				height, errHeight := strconv.ParseFloat(strings.TrimSpace(heightInput.Text), 64)
+				fontSize, errFont := strconv.ParseFloat(strings.TrimSpace(fontSizeInput.Text), 64)
+
+				if strings.TrimSpace(nameInput.Text) == "" || typeSelector.Selected == "" ||
+					errWidth != nil || errHeight != nil || errFont != nil ||
+					width <= 2*labelMargin || height <= 2*labelMargin || fontSize <= 0 {
+					dialog.ShowInformation("Error", "All fields must be filled with valid sizes", window)
+					return
+				}
+
+				t.name = strings.TrimSpace(nameInput.Text)
+				t.widthMM = width
+				t.heightMM = height
+				t.barcodeType = typeSelector.Selected
+				t.fontSize = fontSize
+
+				if err := saveLabelTemplate(db, t); err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+				showLabelTemplates(window, db)
+			}
+		}, window)
+}
+
+func formatMM(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"database/sql"
+	"image/color"
+	"log"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// A material row of a location to choose from
+type MaterialChoice struct {
+	materialID    int
+	stockID       string
+	customerID    int
+	customerName  string
+	locationID    int
+	locationName  string
+	warehouseName string
+	owner         string
+	quantity      int
+	description   string
+}
+
+var materialChoiceColumns = []string{"Location", "Warehouse", "Stock ID", "Owner", "Quantity", "Description"}
+
+func (m MaterialChoice) getColumns() []string {
+	return []string{
+		m.locationName,
+		m.warehouseName,
+		m.stockID,
+		m.owner,
+		strconv.Itoa(m.quantity),
+		m.description,
+	}
+}
+
+// Short text of the material for the dialog titles and messages
+func (m MaterialChoice) String() string {
+	return m.stockID + " (" + m.owner + ") in " + m.locationName + ", " + m.warehouseName
+}
+
+func (m MaterialChoice) matches(search string) bool {
+	for _, column := range append(m.getColumns(), m.customerName) {
+		if strings.Contains(strings.ToLower(column), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Materials of a customer in the locations, all customers when customerID is 0
+func fetchMaterialChoices(db *sql.DB, customerID int) ([]MaterialChoice, error) {
+	rows, err := db.Query(`
+		SELECT m.material_id, m.stock_id, COALESCE(m.customer_id, 0), COALESCE(c.name, ''),
+			m.location_id, l.name, w.name, m.owner, m.quantity, COALESCE(i.description, '')
+		FROM materials m
+		JOIN items i ON i.item_id = m.item_id
+		JOIN locations l ON l.location_id = m.location_id
+		JOIN warehouses w ON w.warehouse_id = l.warehouse_id
+		LEFT JOIN customers c ON c.customer_id = m.customer_id
+		WHERE ($1 = 0 OR m.customer_id = $1) AND m.quantity > 0
+		ORDER BY m.stock_id, w.name, l.name, m.owner;`, customerID)
+	if err != nil {
+		log.Println("Error fetchMaterialChoices1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var materials []MaterialChoice
+
+	for rows.Next() {
+		var m MaterialChoice
+		if err := rows.Scan(&m.materialID, &m.stockID, &m.customerID, &m.customerName,
+			&m.locationID, &m.locationName, &m.warehouseName, &m.owner, &m.quantity,
+			&m.description); err != nil {
+			log.Println("Error fetchMaterialChoices2: ", err)
+			return materials, err
+		}
+		materials = append(materials, m)
+	}
+
+	return materials, rows.Err()
+}
+
+// Searchable list of materials, the selection is kept by the material ID
+type MaterialPicker struct {
+	materials  []MaterialChoice
+	filtered   []MaterialChoice
+	selectedID int
+	search     *widget.Entry
+	list       *widget.List
+	OnSelected func(MaterialChoice)
+}
+
+func newMaterialPicker(onSelected func(MaterialChoice)) *MaterialPicker {
+	p := &MaterialPicker{OnSelected: onSelected}
+
+	p.search = widget.NewEntry()
+	p.search.SetPlaceHolder("Search by location, stock ID, owner or description")
+	p.search.OnChanged = func(string) { p.applySearch() }
+
+	p.list = widget.NewList(
+		func() int { return len(p.filtered) },
+		func() fyne.CanvasObject { return newMaterialChoiceRow() },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			labels := item.(*fyne.Container).Objects
+			for i, column := range p.filtered[id].getColumns() {
+				labels[i].(*widget.Label).SetText(column)
+			}
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		if id >= len(p.filtered) {
+			return
+		}
+		p.selectedID = p.filtered[id].materialID
+		if p.OnSelected != nil {
+			p.OnSelected(p.filtered[id])
+		}
+	}
+
+	return p
+}
+
+func newMaterialChoiceRow() *fyne.Container {
+	row := container.NewGridWithColumns(len(materialChoiceColumns))
+	for range materialChoiceColumns {
+		label := widget.NewLabel("")
+		label.Truncation = fyne.TextTruncateEllipsis
+		row.Add(label)
+	}
+
+	return row
+}
+
+// Replace the materials, the selection is cleared
+func (p *MaterialPicker) setMaterials(materials []MaterialChoice) {
+	p.materials = materials
+	p.selectedID = 0
+	p.list.UnselectAll()
+	p.applySearch()
+}
+
+func (p *MaterialPicker) applySearch() {
+	search := strings.ToLower(strings.TrimSpace(p.search.Text))
+
+	p.filtered = nil
+	for _, m := range p.materials {
+		if search == "" || m.matches(search) {
+			p.filtered = append(p.filtered, m)
+		}
+	}
+
+	// Keep the selected material highlighted when it is still shown
+	p.list.UnselectAll()
+	for i, m := range p.filtered {
+		if m.materialID == p.selectedID {
+			p.list.Select(i)
+		}
+	}
+	p.list.Refresh()
+}
+
+// The chosen material, false when nothing is chosen
+func (p *MaterialPicker) getSelected() (MaterialChoice, bool) {
+	for _, m := range p.materials {
+		if m.materialID == p.selectedID {
+			return m, true
+		}
+	}
+
+	return MaterialChoice{}, false
+}
+
+// The search, the column headers and the list sized for the dialogs
+func (p *MaterialPicker) content() fyne.CanvasObject {
+	header := newMaterialChoiceRow()
+	for i, column := range materialChoiceColumns {
+		label := header.Objects[i].(*widget.Label)
+		label.SetText(column)
+		label.TextStyle.Bold = true
+	}
+
+	minSize := canvas.NewRectangle(color.Transparent)
+	minSize.SetMinSize(fyne.NewSize(760, 260))
+
+	return container.NewBorder(container.NewVBox(p.search, header), nil, nil, nil,
+		container.NewStack(minSize, p.list))
+}
@@ -5,10 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -17,14 +17,31 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
 )
 
 var materialTypes = []string{"Envelope", "Card", "Carrier", "Insert", "Consumables"}
 
+// Transaction types of the transactions_log entries
+const (
+	receiptTrx    = "Receipt"
+	usageTrx      = "Usage"
+	moveTrx       = "Move"
+	adjustmentTrx = "Adjustment"
+	ownershipTrx  = "Ownership"
+)
+
 type Location struct {
-	id          int    `field:"location_id"`
-	name        string `field:"name"`
-	warehouseID int    `field:"warehouse_id"`
+	id            int    `field:"location_id"`
+	name          string `field:"name"`
+	warehouseID   int    `field:"warehouse_id"`
+	warehouseName string
+}
+
+// The names of the locations repeat in the warehouses
+func (l Location) getLabel() string {
+	return l.warehouseName + " / " + l.name
 }
 
 type Customer struct {
@@ -34,46 +51,46 @@ type Customer struct {
 }
 
 type MaterialInfo struct {
-	materialId   int       `field:"material_id"`
-	stockId      string    `field:"stock_id"`
-	locationId   int       `field:"location_id"`
-	customerId   int       `field:"customer_id"`
-	materialType string    `field:"material_type"`
-	description  string    `field:"description"`
-	notes        string    `field:"notes"`
-	quantity     int       `field:"quantity"`
-	updatedAt    time.Time `field:"updated_at"`
-	isActive     bool      `field:"is_active"`
-	cost         float64   `field:"cost"`
-	minQty       int       `field:"min_required_quantity"`
-	maxQty       int       `field:"max_required_quantity"`
-	owner        string    `field:"onwer"`
+	materialId int             `field:"material_id"`
+	itemId     int             `field:"item_id"`
+	stockId    string          `field:"stock_id"`
+	locationId int             `field:"location_id"`
+	customerId int             `field:"customer_id"`
+	notes      string          `field:"notes"`
+	quantity   int             `field:"quantity"`
+	updatedAt  time.Time       `field:"updated_at"`
+	cost       decimal.Decimal `field:"cost"`
+	owner      string          `field:"onwer"`
 }
 
 type IncomingMaterial struct {
-	ShippingID   int     `field:"shipping_id"`
-	CustomerName string  `field:"customer_name"`
-	StockID      string  `field:"stock_id"`
-	Cost         float64 `field:"cost"`
-	Quantity     int     `field:"quantity"`
-	MinQty       int     `field:"min_required_quantity"`
-	MaxQty       int     `field:"max_required_quantity"`
-	Notes        string  `field:"notes"`
-	IsActive     bool    `field:"is_active"`
-	MaterialType string  `field:"type"`
-	Owner        string  `field:"owner"`
+	ShippingID   int             `field:"shipping_id"`
+	CustomerName string          `field:"customer_name"`
+	StockID      string          `field:"stock_id"`
+	Cost         decimal.Decimal `field:"cost"`
+	Quantity     int             `field:"quantity"`
+	MinQty       int             `field:"min_required_quantity"`
+	MaxQty       int             `field:"max_required_quantity"`
+	Notes        string          `field:"notes"`
+	IsActive     bool            `field:"is_active"`
+	MaterialType string          `field:"type"`
+	Owner        string          `field:"owner"`
+	CreatedAt    time.Time       `field:"created_at"`
 }
 
 type TransactionInfo struct {
-	materialId    int       `field:"material_id"`
-	stockId       string    `field:"stock_id"`
-	quantity      int       `field:"quantity_change"`
-	notes         string    `field:"notes"`
-	cost          float64   `field:"cost"`
-	updatedAt     time.Time `field:"updated_at"`
-	jobTicket     string    `field:"job_ticket"`
-	isMove        bool      // opts
-	newMaterialId int       // opts
+	materialId     int             `field:"material_id"`
+	stockId        string          `field:"stock_id"`
+	quantity       int             `field:"quantity_change"`
+	notes          string          `field:"notes"`
+	cost           decimal.Decimal `field:"cost"`
+	updatedAt      time.Time       `field:"updated_at"`
+	jobTicket      string          `field:"job_ticket"`
+	trxType        string          `field:"transaction_type"`
+	isMove         bool            // opts
+	newMaterialId  int             // opts
+	fromLocationId int             // opts, the source location of a move
+	toLocationId   int             // opts, the destination location of a move
 }
 
 type MaterialOpts struct {
@@ -83,7 +100,7 @@ type MaterialOpts struct {
 	quantity     int
 	minQty       int
 	maxQty       int
-	cost         float64
+	cost         decimal.Decimal
 	materialType string
 	isActive     bool
 	notes        string
@@ -99,13 +116,6 @@ type LocationOpts struct {
 // FETCH DATA FROM THE DB
 //////////////////////////////////////////
 
-func getIncomingMaterialsNumber(db *sql.DB) int {
-	var incomingMaterialsQty int
-	row := db.QueryRow("SELECT count(shipping_id) FROM incoming_materials;")
-	row.Scan(&incomingMaterialsQty)
-	return incomingMaterialsQty
-}
-
 func fetchCustomers(db *sql.DB) ([]Customer, error) {
 	rows, err := db.Query("SELECT * FROM customers;")
 	if err != nil {
@@ -131,14 +141,17 @@ func fetchCustomers(db *sql.DB) ([]Customer, error) {
 	return customers, nil
 }
 
+// Empty locations and the locations with the same stock ID of the customer
 func fetchAvailableLocations(db *sql.DB, locOpts *LocationOpts) ([]Location, error) {
 	rows, err := db.Query(`
-		SELECT l.location_id, l.name, l.warehouse_id 
+		SELECT DISTINCT l.location_id, l.name, l.warehouse_id, COALESCE(w.name, '')
 		FROM locations l
-		LEFT JOIN materials m ON m.location_id = l.location_id
+		LEFT JOIN warehouses w ON w.warehouse_id = l.warehouse_id
+		LEFT JOIN materials m ON m.location_id = l.location_id AND m.quantity > 0
 		WHERE
 			(m.customer_id = $1 AND m.stock_id = $2)
-			OR m.material_id IS NULL`,
+			OR m.material_id IS NULL
+		ORDER BY COALESCE(w.name, ''), l.name`,
 		locOpts.customerId, locOpts.stockId)
 	if err != nil {
 		log.Println("Error fetchAvailableLocations1: ", err)
@@ -150,7 +163,7 @@ func fetchAvailableLocations(db *sql.DB, locOpts *LocationOpts) ([]Location, err
 
 	for rows.Next() {
 		var location Location
-		if err := rows.Scan(&location.id, &location.name, &location.warehouseID); err != nil {
+		if err := rows.Scan(&location.id, &location.name, &location.warehouseID, &location.warehouseName); err != nil {
 			log.Println("Error fetchAvailableLocations2: ", err)
 			return locations, err
 		}
@@ -163,202 +176,144 @@ func fetchAvailableLocations(db *sql.DB, locOpts *LocationOpts) ([]Location, err
 	return locations, nil
 }
 
-func fetchMaterialsByCustomer(db *sql.DB, customerId int) ([]Material, error) {
-	rows, err := db.Query(`SELECT m.material_id, m.stock_id, l.name, m.description,
-							m.notes, m.quantity, m.updated_at, c.name, m.material_type,
-							m.cost, m.owner
-							FROM materials m
-							LEFT JOIN locations l ON m.location_id = l.location_id
-							LEFT JOIN customers c ON c.customer_id = m.customer_id
-							WHERE m.customer_id = $1`, customerId)
-	if err != nil {
-		log.Println("Error fetchMaterialsByCustomer1: ", err)
-		return nil, err
-	}
-	defer rows.Close()
+// Write the entries of a receipt or of an issue from the oldest cost layers.
+// The value of the quantity at the costs of its layers is returned. An issue
+// locks the material row, run it in a transaction so that two issues of the
+// same material do not take the same layers.
+func addTranscation(trx *TransactionInfo, db queryExecutor) (decimal.Decimal, error) {
+	value := decimal.Zero
 
-	var materials []Material
+	if trx.quantity < 0 {
+		removingQty := -trx.quantity
 
-	for rows.Next() {
-		var material Material
-		if err := rows.Scan(
-			&material.MaterialID,
-			&material.StockID,
-			&material.LocationName,
-			&material.Description,
-			&material.Notes,
-			&material.Quantity,
-			&material.UpdatedAt,
-			&material.CustomerName,
-			&material.MaterialType,
-			&material.Cost,
-			&material.Owner,
-		); err != nil {
-			log.Println("Error fetchMaterialsByCustomer2: ", err)
-			return materials, err
+		if _, err := db.Exec(`SELECT 1 FROM materials WHERE material_id = $1 FOR UPDATE;`,
+			trx.materialId); err != nil {
+			log.Println("Error addTranscation1: ", err)
+			return value, err
 		}
-		materials = append(materials, material)
-	}
-	if err = rows.Err(); err != nil {
-		return materials, err
-	}
 
-	return materials, nil
-}
-
-func addTranscation(trx *TransactionInfo, db *sql.DB) error {
-	if trx.quantity < 0 {
-		removingQty := int(math.Abs(float64(trx.quantity)))
-
-		emptyCost := []string{"0"}
+		// Every incoming entry is a cost layer, the issued quantity is taken
+		// from the oldest layers first, so a layer keeps what was received
+		// after it minus the issued quantity, at most its own quantity
+		rows, err := db.Query(`
+			WITH layers AS (
+				SELECT transaction_id, cost, quantity_change,
+					SUM(quantity_change) OVER (ORDER BY transaction_id) AS received_quantity
+				FROM transactions_log
+				WHERE material_id = $1 AND stock_id = $2 AND quantity_change > 0
+			), issued AS (
+				SELECT COALESCE(-SUM(quantity_change), 0) AS quantity
+				FROM transactions_log
+				WHERE material_id = $1 AND stock_id = $2 AND quantity_change < 0
+			)
+			SELECT l.cost, LEAST(l.quantity_change, l.received_quantity - i.quantity) AS remaining_quantity
+			FROM layers l, issued i
+			WHERE l.received_quantity > i.quantity
+			ORDER BY l.transaction_id;`,
+			trx.materialId, trx.stockId)
+		if err != nil {
+			log.Println("Error addTranscation2: ", err)
+			return value, err
+		}
 
-		for removingQty > 0 {
-			var transactionId int
-			var cost float64
-			var remainingQty int
+		var layers []TransactionInfo
+		for rows.Next() {
+			var layer TransactionInfo
+			if err := rows.Scan(&layer.cost, &layer.quantity); err != nil {
+				rows.Close()
+				log.Println("Error addTranscation3: ", err)
+				return value, err
+			}
+			layers = append(layers, layer)
+		}
+		rows.Close()
 
-			// Find a last deduction
-			db.QueryRow(`
-				SELECT transaction_id, cost, remaining_quantity FROM transactions_log
-				WHERE material_id = $1 AND stock_id = $2 AND quantity_change < 0
-					AND cost NOT IN (`+strings.Join(emptyCost, ",")+`)
-				ORDER BY transaction_id DESC LIMIT 1;
-						`,
-				trx.materialId,
-				trx.stockId).Scan(&transactionId, &cost, &remainingQty)
-
-			// First deduction is NOT found
-			if transactionId == 0 {
-				db.QueryRow(`
-					SELECT transaction_id, cost, remaining_quantity FROM transactions_log
-					WHERE material_id = $1 AND stock_id = $2  AND quantity_change > 0
-						AND cost NOT IN (`+strings.Join(emptyCost, ",")+`)
-					ORDER BY transaction_id LIMIT 1;
-							`,
-					trx.materialId,
-					trx.stockId,
-				).Scan(&transactionId, &cost, &remainingQty)
-
-				// When neither positive nor negative calculations found
-				if transactionId == 0 {
-					return errors.New("no remains found")
-				}
+		// When neither positive nor negative calculations found
+		if len(layers) == 0 {
+			return value, errors.New("no remains found")
+		}
 
-				// First deduction is found, but remains are zero
-			} else if transactionId != 0 && remainingQty == 0 {
-				emptyCost = append(emptyCost, strconv.FormatFloat(cost, 'f', -1, 64))
-				continue
+		// Deduct from the balance layer by layer
+		for _, layer := range layers {
+			if removingQty == 0 {
+				break
 			}
 
-			// Deduct from the balance
-			if remainingQty < removingQty {
-				removingQty -= remainingQty
-
-				_, errInsert := db.Exec(
-					`INSERT INTO transactions_log
-							(material_id, stock_id, quantity_change, notes,
-							cost, job_ticket, updated_at, remaining_quantity)
-							 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-							 `, trx.materialId, trx.stockId, -remainingQty, trx.notes,
-					cost, trx.jobTicket, trx.updatedAt, 0)
-
-				if errInsert != nil {
-					log.Println("err1", errInsert)
-					return errInsert
-				}
+			deductQty := min(layer.quantity, removingQty)
 
-				emptyCost = append(emptyCost, strconv.FormatFloat(cost, 'f', -1, 64))
-
-				if trx.isMove {
-					addTranscation(&TransactionInfo{
-						materialId: trx.newMaterialId,
-						stockId:    trx.stockId,
-						quantity:   remainingQty,
-						notes:      trx.notes,
-						cost:       cost,
-						updatedAt:  trx.updatedAt,
-						jobTicket:  trx.jobTicket,
-					}, db)
-				}
-			} else if remainingQty >= removingQty {
-				remainingQty -= removingQty
-
-				_, errInsert := db.Exec(
-					`INSERT INTO transactions_log
-							(material_id, stock_id, quantity_change, notes,
-							cost, job_ticket, updated_at, remaining_quantity)
-							 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-							 `, trx.materialId, trx.stockId, -removingQty, trx.notes,
-					cost, trx.jobTicket, trx.updatedAt, remainingQty)
-
-				if errInsert != nil {
-					log.Println("err2", errInsert)
-					return errInsert
+			errInsert := insertTransaction(db, trx, -deductQty, layer.cost, layer.quantity-deductQty)
+			if errInsert != nil {
+				log.Println("Error addTranscation4: ", errInsert)
+				return value, errInsert
+			}
+			value = value.Add(layer.cost.Mul(decimal.New(int64(deductQty), 0)))
+
+			if trx.isMove {
+				if _, err := addTranscation(&TransactionInfo{
+					materialId:     trx.newMaterialId,
+					stockId:        trx.stockId,
+					quantity:       deductQty,
+					notes:          trx.notes,
+					cost:           layer.cost,
+					updatedAt:      trx.updatedAt,
+					jobTicket:      trx.jobTicket,
+					trxType:        trx.trxType,
+					fromLocationId: trx.fromLocationId,
+					toLocationId:   trx.toLocationId,
+				}, db); err != nil {
+					return value, err
 				}
+			}
 
-				if trx.isMove {
-					addTranscation(&TransactionInfo{
-						materialId: trx.newMaterialId,
-						stockId:    trx.stockId,
-						quantity:   removingQty,
-						notes:      trx.notes,
-						cost:       cost,
-						updatedAt:  trx.updatedAt,
-						jobTicket:  trx.jobTicket,
-					}, db)
-				}
+			removingQty -= deductQty
+		}
 
-				removingQty = 0
-			}
+		if removingQty > 0 {
+			return value, errors.New("no remains found for " + strconv.Itoa(removingQty) + " of " + trx.stockId)
 		}
 	} else {
-		// Check if an ID with the same cost exists
-		var transactionId int
-		db.QueryRow(`
-				SELECT transaction_id FROM transactions_log
-				WHERE
-					material_id = $1 AND
-					stock_id = $2 AND
-					quantity_change > 0 AND
-					cost = $3
-				ORDER BY transaction_id DESC LIMIT 1;
-						`,
-			trx.materialId, trx.stockId, trx.cost).Scan(&transactionId)
-
-		// If the ID exists then update it
-		if transactionId > 0 {
-			_, e := db.Query(`
-				UPDATE transactions_log
-				SET quantity_change = quantity_change + $2,
-					remaining_quantity = remaining_quantity + $2,
-					updated_at = NOW()
-				WHERE transaction_id = $1
-
-		`, transactionId, trx.quantity)
-
-			if e != nil {
-				return e
-			}
-		} else {
-			// If an ID doesn't exist then add a new one
-			_, e := db.Exec(
-				`INSERT INTO transactions_log
-			(material_id, stock_id, quantity_change, notes,
-			cost, job_ticket, updated_at, remaining_quantity)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-			 `, trx.materialId, trx.stockId, trx.quantity, trx.notes,
-				trx.cost, trx.jobTicket, trx.updatedAt, trx.quantity)
-
-			if e != nil {
-				return e
-			}
+		// Every receipt is kept as a separate cost layer
+		e := insertTransaction(db, trx, trx.quantity, trx.cost, trx.quantity)
+		if e != nil {
+			return value, e
 		}
+		value = trx.cost.Mul(decimal.New(int64(trx.quantity), 0))
+	}
+
+	return value, nil
+}
+
+// A log entry keeps the location, the warehouse, the customer, the owner and
+// the type of the material at the time of the transaction
+func insertTransaction(db queryExecutor, trx *TransactionInfo, quantity int, cost decimal.Decimal, remaining int) error {
+	result, err := db.Exec(`
+		INSERT INTO transactions_log
+			(material_id, stock_id, quantity_change, notes, cost, job_ticket,
+			updated_at, remaining_quantity, transaction_type,
+			location_id, warehouse_id, customer_id, owner, material_type,
+			from_location_id, to_location_id)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9,
+			m.location_id, l.warehouse_id, m.customer_id, m.owner, i.material_type,
+			NULLIF($10, 0), NULLIF($11, 0)
+		FROM materials m
+		JOIN items i ON i.item_id = m.item_id
+		LEFT JOIN locations l ON l.location_id = m.location_id
+		WHERE m.material_id = $1;`,
+		trx.materialId, trx.stockId, quantity, trx.notes, cost, trx.jobTicket,
+		trx.updatedAt, remaining, trx.trxType, trx.fromLocationId, trx.toLocationId)
+	if err != nil {
+		log.Println("Error insertTransaction: ", err)
+		return err
+	}
+
+	if inserted, _ := result.RowsAffected(); inserted == 0 {
+		return errors.New("unknown material " + strconv.Itoa(trx.materialId))
 	}
 
 	return nil
 }
 
-func deleteIncomingMaterial(db *sql.DB, shippingId int) error {
+func deleteIncomingMaterial(db queryExecutor, shippingId int) error {
 	if _, err := db.Exec(`
 			DELETE FROM incoming_materials WHERE shipping_id = $1;`,
 		shippingId); err != nil {
@@ -373,7 +328,8 @@ func deleteIncomingMaterial(db *sql.DB, shippingId int) error {
 // Update the current materials quantity within locations
 //////////////////////////////////////////////////////////
 
-// Send a material for warehouse handling
+// Send a material for warehouse handling, the stock ID is picked from the
+// catalog of the customer or a new item is added to it
 func sendMaterial(myWindow fyne.Window, db *sql.DB) {
 	customers, _ := fetchCustomers(db)
 	var customersStr []string
@@ -383,17 +339,14 @@ func sendMaterial(myWindow fyne.Window, db *sql.DB) {
 		customersMap[customer.name] = customer.id
 	}
 
-	customerInputSelector := widget.NewSelect(customersStr, func(s string) {})
-	customerInputSelector.SetSelected(customersStr[0])
-
-	stockIDInput := widget.NewEntry()
+	stockIDInput := widget.NewSelectEntry(nil)
 	stockIDInput.Validator = validation.NewRegexp(".+", "At least one character")
 
 	typeSelector := widget.NewSelect(materialTypes, func(s string) {})
 	typeSelector.SetSelected(materialTypes[0])
 
-	quantityInput := widget.NewEntry()
-	quantityInput.Validator = validation.NewRegexp(
+	quantityInput := newUnitQuantityInput()
+	quantityInput.quantity.Validator = validation.NewRegexp(
 		`^[1-9][0-9]*$`,
 		"Positive numbers greater than 0 only",
 	)
@@ -409,13 +362,66 @@ func sendMaterial(myWindow fyne.Window, db *sql.DB) {
 	descrInput := widget.NewEntry()
 	ownerChkBox := widget.NewCheck("", func(b bool) {})
 	isActiveChkBox := widget.NewCheck("", func(b bool) {})
+	isActiveChkBox.SetChecked(true)
+	itemLabel := widget.NewLabel("")
+
+	// Items of the selected customer by stock ID
+	itemsMap := make(map[string]Item)
+	itemFields := []fyne.Disableable{typeSelector, minRequiredQtyInput, maxRequiredQtyInput, descrInput, isActiveChkBox}
+
+	var customerInputSelector *widget.Select
+	stockIDInput.OnChanged = func(stockID string) {
+		units, _ := fetchStockUnits(db, customerInputSelector.Selected, strings.TrimSpace(stockID))
+		quantityInput.setUnits(units)
+
+		item, ok := itemsMap[strings.TrimSpace(stockID)]
+		if !ok {
+			itemLabel.SetText("New item, it is added to the catalog")
+			for _, field := range itemFields {
+				field.Enable()
+			}
+			return
+		}
+
+		// The catalog keeps the details of the item
+		itemLabel.SetText("Catalog item, " + item.unit)
+		typeSelector.SetSelected(item.materialType)
+		descrInput.SetText(item.description)
+		minRequiredQtyInput.SetText("")
+		if item.minQty > 0 {
+			minRequiredQtyInput.SetText(strconv.Itoa(item.minQty))
+		}
+		maxRequiredQtyInput.SetText("")
+		if item.maxQty > 0 {
+			maxRequiredQtyInput.SetText(strconv.Itoa(item.maxQty))
+		}
+		isActiveChkBox.SetChecked(item.isActive)
+		ownerChkBox.SetChecked(item.defaultOwner == "Tag")
+		for _, field := range itemFields {
+			field.Disable()
+		}
+	}
+
+	customerInputSelector = widget.NewSelect(customersStr, func(customerName string) {
+		items, _ := fetchItems(db, customersMap[customerName])
+		itemsMap = make(map[string]Item)
+		var stockIDs []string
+		for _, item := range items {
+			itemsMap[item.stockID] = item
+			stockIDs = append(stockIDs, item.stockID)
+		}
+		stockIDInput.SetOptions(stockIDs)
+		stockIDInput.OnChanged(stockIDInput.Text)
+	})
+	customerInputSelector.SetSelected(customersStr[0])
 
 	dialog := dialog.NewForm("Sending a Material to the Warehouse", "Send", "Cancel",
 		[]*widget.FormItem{
 			widget.NewFormItem("Customer *", customerInputSelector),
 			widget.NewFormItem("Stock ID *", stockIDInput),
+			widget.NewFormItem("", itemLabel),
 			widget.NewFormItem("Type *", typeSelector),
-			widget.NewFormItem("Quantity *", quantityInput),
+			widget.NewFormItem("Quantity *", quantityInput.content()),
 			widget.NewFormItem("Unit Cost, USD *", costInput),
 			widget.NewFormItem("Min Quantity", minRequiredQtyInput),
 			widget.NewFormItem("Max Quantity", maxRequiredQtyInput),
@@ -424,23 +430,59 @@ func sendMaterial(myWindow fyne.Window, db *sql.DB) {
 			widget.NewFormItem("Allow for use", isActiveChkBox),
 		}, func(confirm bool) {
 			if confirm {
-				floatCost, _ := strconv.ParseFloat((strings.Replace(costInput.Text, ",", "", -1)), 32)
-				cost := math.Round(floatCost*100) / 100
+				cost, err := parseUnitCost(costInput.Text)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
 
 				owner := "Tag"
 				if !ownerChkBox.Checked {
 					owner = "Customer"
 				}
 
-				_, err := db.Query(`
+				quantity, err := quantityInput.getBaseQuantity()
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
+
+				stockID := strings.TrimSpace(stockIDInput.Text)
+				item, ok := itemsMap[stockID]
+				if !ok {
+					minQty, errMin := parseOptionalQuantity(minRequiredQtyInput.Text)
+					maxQty, errMax := parseOptionalQuantity(maxRequiredQtyInput.Text)
+					if errMin != nil || errMax != nil {
+						dialog.ShowInformation("Error", "The min and max quantities must be whole numbers", myWindow)
+						return
+					}
+
+					item = Item{
+						customerID:   customersMap[customerInputSelector.Selected],
+						stockID:      stockID,
+						materialType: typeSelector.Selected,
+						description:  strings.TrimSpace(descrInput.Text),
+						unit:         defaultItemUnit,
+						minQty:       minQty,
+						maxQty:       maxQty,
+						isActive:     isActiveChkBox.Checked,
+						defaultOwner: owner,
+					}
+					if item.id, err = saveItem(db, item); err != nil {
+						dialog.ShowInformation("Error", err.Error(), myWindow)
+						return
+					}
+				}
+
+				_, err = db.Exec(`
 				INSERT INTO incoming_materials
 					(customer_name, stock_id, cost, quantity,
 					max_required_quantity, min_required_quantity,
 					notes, is_active, type, owner)
 				VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
-					customerInputSelector.Selected, stockIDInput.Text, cost,
-					quantityInput.Text, maxRequiredQtyInput.Text, minRequiredQtyInput.Text,
-					descrInput.Text, isActiveChkBox.Checked, typeSelector.Selected,
+					customerInputSelector.Selected, item.stockID, cost,
+					quantity, item.maxQty, item.minQty,
+					item.description, item.isActive, item.materialType,
 					owner,
 				)
 
@@ -492,6 +534,7 @@ func getIncomingMaterials(db *sql.DB) []IncomingMaterial {
 			material.IsActive,
 			material.MaterialType,
 			material.Owner,
+			material.CreatedAt,
 		})
 	}
 
@@ -501,49 +544,97 @@ func getIncomingMaterials(db *sql.DB) []IncomingMaterial {
 func acceptIncomingMaterials(app fyne.App, db *sql.DB) {
 	window := app.NewWindow("Incoming Materials")
 
-	materialsArr := getIncomingMaterials(db)
-
-	materialWidgets := []fyne.CanvasObject{}
-
-	for i := 0; i < len(materialsArr); i++ {
-		material := materialsArr[i]
-
-		materialWidgets = append(materialWidgets,
-			container.New(layout.NewGridLayoutWithColumns(4),
-				widget.NewLabel("Customer: "+material.CustomerName),
-				widget.NewLabel("Stock ID: "+material.StockID),
-				widget.NewLabel("Quantity: "+strconv.Itoa(material.Quantity)),
-				widget.NewButton("Add", func() {
-
-					var materialOpts = MaterialOpts{
-						shippingId:   material.ShippingID,
-						customerName: material.CustomerName,
-						stockID:      material.StockID,
-						quantity:     material.Quantity,
-						maxQty:       material.MaxQty,
-						minQty:       material.MinQty,
-						cost:         material.Cost,
-						materialType: material.MaterialType,
-						isActive:     material.IsActive,
-						notes:        material.Notes,
-						owner:        material.Owner,
-					}
+	incoming := newIncomingMaterialsList(window, db)
 
-					createMaterial(app, window, db, &materialOpts)
-				}),
-			),
-			widget.NewSeparator(),
-		)
-	}
+	// The list is refreshed when shipments are sent or accepted on any workstation
+	unsubscribe := changes.subscribe(incoming.reload, incomingMaterialsTable)
+	window.SetOnClosed(unsubscribe)
 
-	vBox := container.New(layout.NewVBoxLayout(), materialWidgets...)
-	window.SetContent(vBox)
+	window.SetContent(incoming.list)
 	window.Resize(fyne.NewSize(800, 700))
 	window.Show()
 }
 
-// Create a new material in a location
-func createMaterial(app fyne.App, myWindow fyne.Window, db *sql.DB, materialOpts *MaterialOpts) {
+// Shipments waiting for acceptance. They are reloaded from the change
+// notifications while the list reads them, so they are guarded by mu.
+type IncomingMaterialsList struct {
+	window    fyne.Window
+	db        *sql.DB
+	mu        sync.Mutex
+	materials []IncomingMaterial
+	list      *widget.List
+}
+
+func newIncomingMaterialsList(window fyne.Window, db *sql.DB) *IncomingMaterialsList {
+	l := &IncomingMaterialsList{
+		window:    window,
+		db:        db,
+		materials: getIncomingMaterials(db),
+	}
+
+	l.list = widget.NewList(
+		func() int {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			return len(l.materials)
+		},
+		func() fyne.CanvasObject {
+			return container.New(layout.NewGridLayoutWithColumns(4),
+				widget.NewLabel(""),
+				widget.NewLabel(""),
+				widget.NewLabel(""),
+				widget.NewButton("Add", nil),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			l.mu.Lock()
+			if id >= len(l.materials) {
+				l.mu.Unlock()
+				return
+			}
+			material := l.materials[id]
+			l.mu.Unlock()
+
+			cells := o.(*fyne.Container).Objects
+			cells[0].(*widget.Label).SetText("Customer: " + material.CustomerName)
+			cells[1].(*widget.Label).SetText("Stock ID: " + material.StockID)
+			cells[2].(*widget.Label).SetText("Quantity: " + strconv.Itoa(material.Quantity))
+			cells[3].(*widget.Button).OnTapped = func() {
+				var materialOpts = MaterialOpts{
+					shippingId:   material.ShippingID,
+					customerName: material.CustomerName,
+					stockID:      material.StockID,
+					quantity:     material.Quantity,
+					maxQty:       material.MaxQty,
+					minQty:       material.MinQty,
+					cost:         material.Cost,
+					materialType: material.MaterialType,
+					isActive:     material.IsActive,
+					notes:        material.Notes,
+					owner:        material.Owner,
+				}
+
+				createMaterial(l.window, l.db, &materialOpts, l.reload)
+			}
+		})
+
+	return l
+}
+
+// Fetch the shipments again and refresh the list
+func (l *IncomingMaterialsList) reload() {
+	materials := getIncomingMaterials(l.db)
+
+	l.mu.Lock()
+	l.materials = materials
+	l.mu.Unlock()
+
+	l.list.Refresh()
+}
+
+// Create a new material in a location, onAccepted refreshes the shipments
+func createMaterial(myWindow fyne.Window, db *sql.DB, materialOpts *MaterialOpts, onAccepted func()) {
 	customers, _ := fetchCustomers(db)
 	customersMap := make(map[string]int)
 	for _, customer := range customers {
@@ -559,10 +650,10 @@ func createMaterial(app fyne.App, myWindow fyne.Window, db *sql.DB, materialOpts
 	)
 
 	var locationsStr []string
-	locationsMap := make(map[string]int)
+	var locationIDs []int
 	for _, location := range locations {
-		locationsStr = append(locationsStr, location.name)
-		locationsMap[location.name] = location.id
+		locationsStr = append(locationsStr, location.getLabel())
+		locationIDs = append(locationIDs, location.id)
 	}
 
 	customerLabel := widget.NewLabel(materialOpts.customerName)
@@ -570,7 +661,9 @@ func createMaterial(app fyne.App, myWindow fyne.Window, db *sql.DB, materialOpts
 	typeLabel := widget.NewLabel(materialOpts.materialType)
 	stockIDLabel := widget.NewLabel(materialOpts.stockID)
 	descrLabel := widget.NewLabel(materialOpts.notes)
-	quantityInput := widget.NewEntry()
+	quantityInput := newUnitQuantityInput()
+	units, _ := fetchStockUnits(db, materialOpts.customerName, materialOpts.stockID)
+	quantityInput.setUnits(units)
 	notesInput := widget.NewEntry()
 	ownerLabel := widget.NewLabel(materialOpts.owner)
 
@@ -580,7 +673,7 @@ func createMaterial(app fyne.App, myWindow fyne.Window, db *sql.DB, materialOpts
 	}
 	isActiveLabel := widget.NewLabel(isActive)
 
-	quantityInput.SetText(strconv.Itoa(materialOpts.quantity))
+	quantityInput.quantity.SetText(strconv.Itoa(materialOpts.quantity))
 
 	dialog := dialog.NewForm("Create Material", "Save", "Cancel",
 		[]*widget.FormItem{
@@ -590,108 +683,31 @@ func createMaterial(app fyne.App, myWindow fyne.Window, db *sql.DB, materialOpts
 			widget.NewFormItem("Ownership", ownerLabel),
 			widget.NewFormItem("Allow for use", isActiveLabel),
 			widget.NewFormItem("Description", descrLabel),
-			widget.NewFormItem("Quantity *", quantityInput),
+			widget.NewFormItem("Quantity *", quantityInput.content()),
 			widget.NewFormItem("Location *", locationSelector),
 			widget.NewFormItem("Notes", notesInput),
 		}, func(confirm bool) {
 			if confirm {
-				var material Material
-				quantity, _ := strconv.Atoi(strings.Replace(quantityInput.Text, ",", "", -1))
-
-				// Update material in the current location
-				rows, err := db.Query(`
-				UPDATE materials
-				SET quantity = (quantity + $1)
-				WHERE stock_id = $2
-					AND location_id = $3
-					AND owner = $4
-				RETURNING material_id;
-				`, quantity,
-					materialOpts.stockID,
-					locationsMap[locationSelector.Selected],
-					materialOpts.owner,
-				)
-
+				quantity, err := quantityInput.getBaseQuantity()
 				if err != nil {
-					log.Println("createMaterial upd2", err)
 					dialog.ShowInformation("Error", err.Error(), myWindow)
-				} else {
-					for rows.Next() {
-						err := rows.Scan(&material.MaterialID)
-						if err != nil {
-							log.Println("createMaterial scan", err)
-						}
-					}
-
-					// If there is no the same material in the current location
-					// Then add the material in the chosen one
-					if material.MaterialID == 0 {
-						err := db.QueryRow(`
-					INSERT INTO materials
-					(
-						stock_id,
-						location_id,
-						customer_id,
-						material_type,
-						description,
-						notes,
-						quantity,
-						updated_at,
-						min_required_quantity,
-						max_required_quantity,
-						is_active,
-						cost,
-						owner
-					)
-					VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13) RETURNING material_id;`,
-							materialOpts.stockID,
-							locationsMap[locationSelector.Selected],
-							customersMap[materialOpts.customerName],
-							materialOpts.materialType,
-							materialOpts.notes,
-							notesInput.Text,
-							quantity,
-							time.Now(),
-							materialOpts.minQty,
-							materialOpts.maxQty,
-							materialOpts.isActive,
-							materialOpts.cost,
-							materialOpts.owner,
-						).Scan(&material.MaterialID)
-
-						if err != nil {
-							log.Println("Error saving material:", err)
-							dialog.ShowInformation("Error", "Unable to save data: "+err.Error(), myWindow)
-							panic(err)
-						}
-					}
+					return
+				}
 
-					// Remove the material from incoming
-					err := deleteIncomingMaterial(db, materialOpts.shippingId)
+				var locationID int
+				if i := locationSelector.SelectedIndex(); i >= 0 {
+					locationID = locationIDs[i]
+				}
 
-					if err != nil {
-						dialog.ShowInformation("Error", "Deleting incoming material: "+err.Error(), myWindow)
-					} else {
-						err := addTranscation(&TransactionInfo{
-							materialId: material.MaterialID,
-							stockId:    materialOpts.stockID,
-							quantity:   quantity,
-							notes:      notesInput.Text,
-							updatedAt:  time.Now(),
-							cost:       materialOpts.cost,
-						}, db)
-
-						if err != nil {
-							dialog.ShowInformation("Error", "Updating transactions error: "+err.Error(), myWindow)
-						} else {
-							dialog.ShowConfirm("Material accepted", "Refresh the list?", func(confirm bool) {
-								if confirm {
-									myWindow.Close()
-									acceptIncomingMaterials(app, db)
-								}
-							}, myWindow)
-						}
+				err = acceptStock(db, materialOpts, locationID, quantity, notesInput.Text)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+				} else {
+					// Without the notifications the list is refreshed here
+					if changes == nil {
+						onAccepted()
 					}
+					dialog.ShowInformation("Success", "Material accepted", myWindow)
 				}
 			}
 		}, myWindow)
@@ -700,8 +716,8 @@ func createMaterial(app fyne.App, myWindow fyne.Window, db *sql.DB, materialOpts
 	dialog.Show()
 }
 
-// Remove a material from a location
-func removeMaterial(myWindow fyne.Window, db *sql.DB) {
+// Customer selector that loads the customer materials to the picker
+func newCustomerMaterialsSelector(db *sql.DB, picker *MaterialPicker) *widget.Select {
 	customers, _ := fetchCustomers(db)
 	var customersStr []string
 	customersMap := make(map[string]int)
@@ -710,332 +726,147 @@ func removeMaterial(myWindow fyne.Window, db *sql.DB) {
 		customersMap[customer.name] = customer.id
 	}
 
-	var materials []Material
-	var materialsStr []string
-	materialsMap := make(map[int][3]string)
-
-	customerSelector := widget.NewSelect(customersStr, func(customerName string) {
-		customerId := customersMap[customerName]
-		materials, _ = fetchMaterialsByCustomer(db, customerId)
-		for _, material := range materials {
-			description := material.Notes
-			if description == "" {
-				description = "No description"
-			}
-			materialsStr = append(materialsStr,
-				material.LocationName+" | "+
-					material.StockID+" | "+
-					material.Owner)
-
-			materialsMap[material.MaterialID] = [3]string{
-				material.StockID,
-				material.LocationName,
-				material.Owner}
-		}
+	return widget.NewSelect(customersStr, func(customerName string) {
+		materials, _ := fetchMaterialChoices(db, customersMap[customerName])
+		picker.setMaterials(materials)
+	})
+}
+
+// Remove a material from a location
+func removeMaterial(myWindow fyne.Window, db *sql.DB) {
+	jobSelector := widget.NewSelect([]string{}, func(s string) {})
+	quantityInput := newUnitQuantityInput()
+
+	// Open jobs and units of the material customer
+	picker := newMaterialPicker(func(material MaterialChoice) {
+		jobSelector.ClearSelected()
+		jobSelector.SetOptions(fetchOpenJobTickets(db, material.customerID))
+		units, _ := fetchStockUnits(db, material.customerName, material.stockID)
+		quantityInput.setUnits(units)
 	})
+	customerSelector := newCustomerMaterialsSelector(db, picker)
+	notesInput := widget.NewEntry()
 
-	dialogCustomer := dialog.NewCustomConfirm("Choose customer", "OK", "", customerSelector,
+	dialogMaterial := dialog.NewForm("Remove material", "Remove", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Material *", picker.content()),
+			widget.NewFormItem("Remove Quantity *", quantityInput.content()),
+			widget.NewFormItem("Job Ticket *", jobSelector),
+			widget.NewFormItem("Notes", notesInput),
+		},
 		func(confirm bool) {
-			if confirm && customerSelector.Selected != "" {
-				stockIDSelect := widget.NewSelect(materialsStr, func(s string) {})
-				quantityInput := widget.NewEntry()
-				notesInput := widget.NewEntry()
-				jobTicketInput := widget.NewEntry()
-
-				dialogMaterial := dialog.NewForm("Remove material", "Remove", "Cancel",
-					[]*widget.FormItem{
-						widget.NewFormItem("Stock ID *", stockIDSelect),
-						widget.NewFormItem("Remove Quantity *", quantityInput),
-						widget.NewFormItem("Job Ticket *", jobTicketInput),
-						widget.NewFormItem("Notes", notesInput),
-					},
+			if confirm {
+				material, ok := picker.getSelected()
+				if !ok {
+					dialog.ShowInformation("Error", "Choose a material to remove", myWindow)
+					return
+				}
+
+				quantity, err := quantityInput.getBaseQuantity()
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
+
+				use := func() {
+					remaining, _, err := useStock(db, material.materialID, quantity, jobSelector.Selected, notesInput.Text)
+					if err != nil {
+						dialog.ShowInformation("Error", err.Error(), myWindow)
+					} else {
+						dialog.ShowInformation("Success", "Material has been removed. The remaining quantity: "+
+							strconv.Itoa(remaining)+" "+quantityInput.getBaseUnit(), myWindow)
+					}
+				}
+
+				// Warn when the unreserved usage takes the material of the other jobs
+				conflict, err := getReservationConflict(db, material.customerID, material.stockID, jobSelector.Selected, quantity)
+				if err != nil || conflict == nil {
+					use()
+					return
+				}
+				dialog.ShowConfirm("Reserved Material",
+					strconv.Itoa(conflict.shortfall)+" of "+material.stockID+" is reserved for the job(s) "+
+						strings.Join(conflict.jobTickets, ", ")+".\nUse it for "+jobSelector.Selected+" anyway?",
 					func(confirm bool) {
 						if confirm {
-							quantity, _ := strconv.Atoi(strings.Replace(quantityInput.Text, ",", "", -1))
-							locationName := strings.Split(stockIDSelect.Selected, " | ")[0]
-							stockId := strings.Split(stockIDSelect.Selected, " | ")[1]
-							owner := strings.Split(stockIDSelect.Selected, " | ")[2]
-
-							var materialId int
-							valuesArr := [3]string{stockId, locationName, owner}
-							for k, v := range materialsMap {
-								if reflect.DeepEqual(v, valuesArr) {
-									materialId = k
-								}
-							}
-
-							jobTicket := jobTicketInput.Text
-							notes := notesInput.Text
-
-							// Verify that we have the remaining materials
-							var actualQuantity int
-							db.QueryRow(`SELECT quantity FROM materials WHERE material_id = $1`, materialId).Scan(&actualQuantity)
-
-							if actualQuantity < quantity {
-								dialog.ShowInformation(
-									"Error",
-									`The removing quantity (`+strconv.Itoa(quantity)+`) is more than the actual one (`+strconv.Itoa(actualQuantity)+`)`,
-									myWindow)
-							} else {
-								var err error
-
-								if actualQuantity == quantity {
-									_, err = db.Exec(`
-										DELETE FROM materials
-										WHERE material_id = $1;
-								`, materialId)
-								} else {
-									// Update the material quantity
-									_, err = db.Exec(`
-										UPDATE materials
-										SET quantity = (quantity - $1),
-											notes = $2
-										WHERE material_id = $3;
-								`, quantity, notes, materialId,
-									)
-								}
-
-								if err != nil {
-									dialog.ShowInformation("Error", "Updating material error: "+err.Error(), myWindow)
-
-								} else {
-									err := addTranscation(&TransactionInfo{
-										materialId: materialId,
-										stockId:    stockId,
-										quantity:   -quantity,
-										notes:      notes,
-										jobTicket:  jobTicket,
-										updatedAt:  time.Now(),
-									}, db)
-
-									if err != nil {
-										dialog.ShowInformation("Error", "Updating transactions error: "+err.Error(), myWindow)
-									} else {
-										dialog.ShowInformation("Success", "Material has been removed. The remaining quantity: "+strconv.Itoa(actualQuantity-quantity), myWindow)
-									}
-								}
-							}
+							use()
 						}
 					}, myWindow)
-
-				dialogMaterial.Resize(fyne.NewSize(600, 300))
-				dialogMaterial.Show()
 			}
 		}, myWindow)
 
-	dialogCustomer.Resize(fyne.NewSize(300, 100))
-	dialogCustomer.Show()
+	dialogMaterial.Resize(fyne.NewSize(900, 600))
+	dialogMaterial.Show()
 }
 
 // Move a material between locations
 func moveMaterial(myWindow fyne.Window, db *sql.DB) {
-	customers, _ := fetchCustomers(db)
-	var customersStr []string
-	customersMap := make(map[string]int)
-	for _, customer := range customers {
-		customersStr = append(customersStr, customer.name)
-		customersMap[customer.name] = customer.id
-	}
-
-	var materials []Material
-	var materialsStr []string
-	materialsMap := make(map[int][3]string)
-
-	customerSelector := widget.NewSelect(customersStr, func(customerName string) {
-		customerId := customersMap[customerName]
-		materials, _ = fetchMaterialsByCustomer(db, customerId)
-		for _, material := range materials {
-			description := material.Notes
-			if description == "" {
-				description = "No description"
-			}
-			materialsStr = append(materialsStr,
-				material.LocationName+" | "+
-					material.StockID+" | "+
-					material.Owner)
-			materialsMap[material.MaterialID] = [3]string{
-				material.StockID,
-				material.LocationName,
-				material.Owner,
+	locationSelector := widget.NewSelect([]string{}, func(s string) {})
+	var locationIDs []int // in the order of the options
+	quantityInput := newUnitQuantityInput()
+
+	// Get empty OR the same stock ID locations of the chosen material
+	picker := newMaterialPicker(func(material MaterialChoice) {
+		locations, _ := fetchAvailableLocations(
+			db,
+			&LocationOpts{
+				customerId: material.customerID,
+				stockId:    material.stockID,
+			},
+		)
+		var locationsStr []string
+		locationIDs = nil
+		for _, location := range locations {
+			if location.id == material.locationID {
+				continue
 			}
+			locationsStr = append(locationsStr, location.getLabel())
+			locationIDs = append(locationIDs, location.id)
 		}
+		locationSelector.ClearSelected()
+		locationSelector.SetOptions(locationsStr)
+
+		units, _ := fetchStockUnits(db, material.customerName, material.stockID)
+		quantityInput.setUnits(units)
 	})
+	customerSelector := newCustomerMaterialsSelector(db, picker)
+	notesInput := widget.NewEntry()
 
-	// Customer Dialog
-	dialogCustomer := dialog.NewCustomConfirm("Choose customer", "OK", "Cancel", customerSelector,
+	// Material move dialog
+	dialogMaterial := dialog.NewForm("Move material", "Move", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Material *", picker.content()),
+			widget.NewFormItem("New Location *", locationSelector),
+			widget.NewFormItem("Move Quantity *", quantityInput.content()),
+			widget.NewFormItem("Notes", notesInput),
+		},
 		func(confirm bool) {
-			if confirm && customerSelector.Selected != "" {
-				stockIDSelector := widget.NewSelect(materialsStr, func(s string) {})
-
-				// Stock ID dialog
-				dialogStockID := dialog.NewCustomConfirm("Choose Stock ID to move", "OK", "Cancel", stockIDSelector, func(confirm bool) {
-					if confirm && stockIDSelector.Selected != "" {
-						stockId := strings.Split(stockIDSelector.Selected, " | ")[1]
-
-						// Get empty OR the same stock ID locations
-						locations, _ := fetchAvailableLocations(
-							db,
-							&LocationOpts{
-								customerId: customersMap[customerSelector.Selected],
-								stockId:    stockId,
-							},
-						)
-						var locationsStr []string
-						locationsMap := make(map[string]int)
-						for _, location := range locations {
-							locationsStr = append(locationsStr, location.name)
-							locationsMap[location.name] = location.id
-						}
+			if confirm {
+				material, ok := picker.getSelected()
+				if !ok || locationSelector.SelectedIndex() < 0 {
+					dialog.ShowInformation("Error", "Choose a material and the new location", myWindow)
+					return
+				}
 
-						locationSelector := widget.NewSelect(locationsStr, func(s string) {})
-						quantityInput := widget.NewEntry()
-						notesInput := widget.NewEntry()
-
-						// Material move dialog
-						dialogMaterial := dialog.NewForm(stockIDSelector.Selected, "Move", "Cancel",
-							[]*widget.FormItem{
-								widget.NewFormItem("New Location *", locationSelector),
-								widget.NewFormItem("Move Quantity *", quantityInput),
-								widget.NewFormItem("Notes", notesInput),
-							},
-							func(confirm bool) {
-								if confirm {
-									currLocationName := strings.Split(stockIDSelector.Selected, " | ")[0]
-									owner := strings.Split(stockIDSelector.Selected, " | ")[2]
-
-									var currMaterialId int
-									valuesArr := [3]string{stockId, currLocationName, owner}
-									for k, v := range materialsMap {
-										if reflect.DeepEqual(v, valuesArr) {
-											currMaterialId = k
-										}
-									}
-
-									currentLocationId := locationsMap[currLocationName]
-									newLocationId := locationsMap[locationSelector.Selected]
-									quantity, _ := strconv.Atoi(strings.Replace(quantityInput.Text, ",", "", -1))
-									notes := notesInput.Text
-
-									var currMaterial MaterialInfo
-
-									var actualQuantity int
-									db.QueryRow(`SELECT quantity FROM materials WHERE material_id = $1`, currMaterialId).Scan(&actualQuantity)
-
-									// Check whether remaining quantity exists
-									if actualQuantity < quantity {
-										dialog.ShowInformation(
-											"Error",
-											`The moving quantity (`+strconv.Itoa(quantity)+`) is more than the actual one (`+strconv.Itoa(actualQuantity)+`)`,
-											myWindow)
-									} else {
-
-										// Update material in the current location
-										err := db.QueryRow(`
-												UPDATE materials
-												SET quantity = (quantity - $1),
-													notes = $2
-												WHERE material_id = $3 AND location_id = $4
-												RETURNING material_id, stock_id, location_id, customer_id, material_type,
-														description, notes, quantity, updated_at, is_active, cost,
-														min_required_quantity, max_required_quantity, owner;
-													`, quantity, notes, currMaterialId, currentLocationId,
-										).Scan(
-											&currMaterial.materialId,
-											&currMaterial.stockId,
-											&currMaterial.locationId,
-											&currMaterial.customerId,
-											&currMaterial.materialType,
-											&currMaterial.description,
-											&currMaterial.notes,
-											&currMaterial.quantity,
-											&currMaterial.updatedAt,
-											&currMaterial.isActive,
-											&currMaterial.cost,
-											&currMaterial.minQty,
-											&currMaterial.maxQty,
-											&currMaterial.owner,
-										)
-
-										if err != nil {
-											log.Println("moveMaterial upd1", err)
-											dialog.ShowInformation("Error", err.Error(), myWindow)
-										} else {
-											// Update material in the new location
-											var newMaterial MaterialInfo
-											rows, err := db.Query(`
-												UPDATE materials
-												SET quantity = (quantity + $1)
-												WHERE
-													stock_id = $2 AND
-													location_id = $3 AND
-													owner = $4
-												RETURNING material_id;
-											`, quantity, stockId, newLocationId, owner,
-											)
-
-											if err != nil {
-												log.Println("moveMaterial upd2", err)
-												dialog.ShowInformation("Error", err.Error(), myWindow)
-											}
-											for rows.Next() {
-												err := rows.Scan(&newMaterial.materialId)
-												if err != nil {
-													log.Println("moveMaterial scan", err)
-												}
-											}
-
-											// If there is no the material in the destination location
-											// Then add the material in there
-											if newMaterial.materialId == 0 {
-												err := db.QueryRow(`
-														INSERT INTO materials
-															(stock_id, location_id,
-															customer_id, material_type, description, notes, quantity, updated_at,
-															cost, is_active, min_required_quantity, max_required_quantity, owner)
-															VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
-															RETURNING material_id;`,
-													stockId, newLocationId,
-													currMaterial.customerId, currMaterial.materialType, currMaterial.description,
-													currMaterial.notes, quantity, time.Now(), currMaterial.cost, currMaterial.isActive,
-													currMaterial.minQty, currMaterial.maxQty, currMaterial.owner).
-													Scan(&newMaterial.materialId)
-
-												if err != nil {
-													log.Println("upd3", err)
-													dialog.ShowInformation("Error", err.Error(), myWindow)
-													panic(err)
-												}
-											}
-
-											addTranscation(&TransactionInfo{
-												materialId:    currMaterial.materialId,
-												stockId:       stockId,
-												quantity:      -quantity,
-												notes:         notes,
-												cost:          currMaterial.cost,
-												updatedAt:     time.Now(),
-												isMove:        true,
-												newMaterialId: newMaterial.materialId,
-											}, db)
-
-											dialog.ShowInformation("Success", strconv.Itoa(quantity)+" of "+
-												stockId+" has been moved from "+currLocationName+
-												" to "+locationSelector.Selected, myWindow)
-										}
-									}
-								}
-							}, myWindow)
-
-						dialogMaterial.Resize(fyne.NewSize(600, 300))
-						dialogMaterial.Show()
-					}
-				}, myWindow)
+				quantity, err := quantityInput.getBaseQuantity()
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
 
-				dialogStockID.Resize(fyne.NewSize(300, 100))
-				dialogStockID.Show()
+				err = moveStock(db, material.materialID, locationIDs[locationSelector.SelectedIndex()], quantity, notesInput.Text)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+				} else {
+					dialog.ShowInformation("Success", strconv.Itoa(quantity)+" "+quantityInput.getBaseUnit()+" of "+
+						material.stockID+" has been moved from "+material.locationName+
+						" to "+locationSelector.Selected, myWindow)
+				}
 			}
 		}, myWindow)
 
-	dialogCustomer.Resize(fyne.NewSize(300, 100))
-	dialogCustomer.Show()
+	dialogMaterial.Resize(fyne.NewSize(900, 600))
+	dialogMaterial.Show()
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Sell stock of a location to the other owner at an agreed price per unit,
+// the transfer document is shown for billing
+func changeOwnership(myApp fyne.App, myWindow fyne.Window, db *sql.DB) {
+	newOwnerLabel := widget.NewLabel("")
+	quantityInput := newUnitQuantityInput()
+	priceInput := widget.NewEntry()
+	priceInput.SetPlaceHolder("Price per base unit, e.g. 0.0137")
+
+	picker := newMaterialPicker(func(material MaterialChoice) {
+		newOwnerLabel.SetText(getOtherOwner(material.owner))
+
+		units, _ := fetchStockUnits(db, material.customerName, material.stockID)
+		quantityInput.setUnits(units)
+	})
+	customerSelector := newCustomerMaterialsSelector(db, picker)
+	notesInput := widget.NewEntry()
+
+	dialogOwnership := dialog.NewForm("Change Ownership", "Transfer", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Material *", picker.content()),
+			widget.NewFormItem("New Owner", newOwnerLabel),
+			widget.NewFormItem("Quantity *", quantityInput.content()),
+			widget.NewFormItem("Transfer Price, USD *", priceInput),
+			widget.NewFormItem("Notes", notesInput),
+		},
+		func(confirm bool) {
+			if confirm {
+				material, ok := picker.getSelected()
+				if !ok {
+					dialog.ShowInformation("Error", "Choose a material", myWindow)
+					return
+				}
+
+				quantity, err := quantityInput.getBaseQuantity()
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
+				price, err := parseUnitCost(priceInput.Text)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
+
+				transferID, err := transferOwnership(db, material.materialID, quantity, price, notesInput.Text)
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
+
+				// Transfer document for billing
+				r := OwnershipTransferReport{
+					Report:     Report{db: db, app: myApp, window: myWindow},
+					trfFilter:  SearchFilter{customerID: material.customerID, customerName: material.customerName},
+					transferID: transferID,
+				}
+				showReportWindow(myApp, "Ownership Transfer No. "+strconv.Itoa(transferID), r, r.getReportList(),
+					"ownership_transfer_"+strconv.Itoa(transferID), fyne.NewSize(1200, 300))
+			}
+		}, myWindow)
+
+	dialogOwnership.Resize(fyne.NewSize(900, 600))
+	dialogOwnership.Show()
+}
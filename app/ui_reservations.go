@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Reserve a customer material for an upcoming job
+func reserveMaterial(myWindow fyne.Window, db *sql.DB) {
+	customers, _ := fetchCustomers(db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	stockIDInput := widget.NewSelectEntry([]string{})
+	jobSelector := widget.NewSelect([]string{}, func(s string) {})
+	quantityInput := widget.NewEntry()
+	requiredDateInput := newDateEntry(myWindow)
+	notesInput := widget.NewEntry()
+	availabilityLabel := widget.NewLabel("")
+
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+
+	// On-hand, reserved and available quantities of the chosen stock ID
+	updateAvailability := func() {
+		customerID := customersMap[customerSelector.Selected]
+		stockID := strings.TrimSpace(stockIDInput.Text)
+		if customerID == 0 || stockID == "" {
+			availabilityLabel.SetText("")
+			return
+		}
+
+		a, err := fetchStockAvailability(db, customerID, stockID)
+		if err != nil {
+			availabilityLabel.SetText(err.Error())
+			return
+		}
+		availabilityLabel.SetText("On hand " + strconv.Itoa(a.onHand) + ", reserved " +
+			strconv.Itoa(a.reserved) + ", available " + strconv.Itoa(a.getAvailable()))
+	}
+	customerSelector.OnChanged = func(customerName string) {
+		stockIDs, _ := fetchCustomerStockIDs(db, customersMap[customerName])
+		stockIDInput.SetOptions(stockIDs)
+		jobSelector.ClearSelected()
+		jobSelector.SetOptions(fetchOpenJobTickets(db, customersMap[customerName]))
+		updateAvailability()
+	}
+	stockIDInput.OnChanged = func(string) { updateAvailability() }
+
+	dialog := dialog.NewForm("Reserve Material", "Reserve", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer *", customerSelector),
+			widget.NewFormItem("Stock ID *", stockIDInput),
+			widget.NewFormItem("Job Ticket *", jobSelector),
+			widget.NewFormItem("Quantity *", quantityInput),
+			widget.NewFormItem("Required Date", requiredDateInput),
+			widget.NewFormItem("Notes", notesInput),
+			widget.NewFormItem("Availability", availabilityLabel),
+		}, func(confirm bool) {
+			if confirm {
+				requiredDate, err := requiredDateInput.GetDate()
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+					return
+				}
+				quantity, _ := strconv.Atoi(strings.Replace(quantityInput.Text, ",", "", -1))
+
+				err = addReservation(db, Reservation{
+					customerID:   customersMap[customerSelector.Selected],
+					stockID:      strings.TrimSpace(stockIDInput.Text),
+					jobTicket:    jobSelector.Selected,
+					quantity:     quantity,
+					requiredDate: toNullTime(requiredDate),
+					notes:        notesInput.Text,
+				})
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+				} else {
+					dialog.ShowInformation("Success", strconv.Itoa(quantity)+" of "+stockIDInput.Text+
+						" has been reserved for the job "+jobSelector.Selected, myWindow)
+				}
+			}
+		}, myWindow)
+
+	dialog.Resize(fyne.NewSize(600, 400))
+	dialog.Show()
+}
+
+// Release the remaining quantity of an open reservation
+func releaseReservation(myWindow fyne.Window, db *sql.DB) {
+	reservations, err := fetchReservations(db, SearchFilter{})
+	if err != nil {
+		dialog.ShowInformation("Error", err.Error(), myWindow)
+		return
+	}
+	if len(reservations) == 0 {
+		dialog.ShowInformation("Cancel Reservation", "There are no open reservations", myWindow)
+		return
+	}
+
+	var reservationsStr []string
+	reservationsMap := make(map[string]int)
+	for _, r := range reservations {
+		name := "#" + strconv.Itoa(r.id) + " " + r.jobTicket + ": " + strconv.Itoa(r.getRemaining()) +
+			" of " + r.stockID + " (" + r.customerName + ")"
+		reservationsStr = append(reservationsStr, name)
+		reservationsMap[name] = r.id
+	}
+
+	reservationSelector := widget.NewSelect(reservationsStr, func(s string) {})
+
+	dialog := dialog.NewForm("Cancel Reservation", "Release", "Close",
+		[]*widget.FormItem{
+			widget.NewFormItem("Reservation *", reservationSelector),
+		}, func(confirm bool) {
+			if confirm && reservationSelector.Selected != "" {
+				if err := cancelReservation(db, reservationsMap[reservationSelector.Selected]); err != nil {
+					dialog.ShowInformation("Error", err.Error(), myWindow)
+				} else {
+					dialog.ShowInformation("Success", "The reservation has been released", myWindow)
+				}
+			}
+		}, myWindow)
+
+	dialog.Resize(fyne.NewSize(600, 150))
+	dialog.Show()
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+const noDateRange = "All dates"
+
+// List of the saved report definitions with the actions to run,
+// edit and delete them
+func showSavedReports(app fyne.App, db *sql.DB, scheduler *ReportScheduler) {
+	window := app.NewWindow("Saved Reports")
+
+	var refresh func()
+	refresh = func() {
+		definitions, err := fetchReportDefinitions(db)
+		if err != nil {
+			dialog.ShowInformation("Error", err.Error(), window)
+		}
+
+		definitionWidgets := []fyne.CanvasObject{
+			widget.NewLabel("Reports are saved to " + getReportsDir()),
+			widget.NewSeparator(),
+		}
+
+		for _, definition := range definitions {
+			d := definition
+
+			schedule := "On demand"
+			if d.schedule != "" {
+				schedule = "Schedule: " + d.schedule
+			}
+			lastRun := "Never run"
+			if d.lastRunAt.Valid {
+				lastRun = "Last run: " + d.lastRunAt.Time.Format("01/02/2006 15:04")
+			}
+
+			nameLabel := widget.NewLabel(d.name)
+			nameLabel.TextStyle.Bold = true
+
+			definitionWidgets = append(definitionWidgets,
+				container.New(layout.NewGridLayoutWithColumns(4),
+					nameLabel,
+					widget.NewLabel(d.reportType+" ("+d.format+")"),
+					widget.NewLabel(schedule),
+					widget.NewLabel(lastRun),
+				),
+				container.New(layout.NewGridLayoutWithColumns(3),
+					widget.NewButton("Run Now", func() {
+						files, err := runReportDefinition(db, d, time.Now())
+						if err != nil {
+							dialog.ShowInformation("Error", err.Error(), window)
+							return
+						}
+						dialog.ShowInformation("Success", "Saved:\n"+strings.Join(files, "\n"), window)
+						refresh()
+					}),
+					widget.NewButton("Edit", func() {
+						editReportDefinition(window, db, d, func() {
+							reloadScheduler(window, scheduler)
+							refresh()
+						})
+					}),
+					widget.NewButton("Delete", func() {
+						dialog.ShowConfirm("Delete", "Delete the report \""+d.name+"\"?", func(confirm bool) {
+							if !confirm {
+								return
+							}
+							if err := deleteReportDefinition(db, d.id); err != nil {
+								dialog.ShowInformation("Error", err.Error(), window)
+								return
+							}
+							reloadScheduler(window, scheduler)
+							refresh()
+						}, window)
+					}),
+				),
+				widget.NewSeparator(),
+			)
+		}
+
+		newButton := widget.NewButton("New Saved Report", func() {
+			editReportDefinition(window, db, ReportDefinition{format: pdfFormat}, func() {
+				reloadScheduler(window, scheduler)
+				refresh()
+			})
+		})
+
+		window.SetContent(container.NewBorder(nil, newButton, nil, nil,
+			container.NewVScroll(container.NewVBox(definitionWidgets...))))
+	}
+
+	refresh()
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+}
+
+func reloadScheduler(window fyne.Window, scheduler *ReportScheduler) {
+	if err := scheduler.reload(); err != nil {
+		dialog.ShowInformation("Error", "The schedules have not been reloaded: "+err.Error(), window)
+	}
+}
+
+// Create or change a definition, onSaved is called after it has been stored
+func editReportDefinition(window fyne.Window, db *sql.DB, d ReportDefinition, onSaved func()) {
+	customers, _ := fetchCustomers(db)
+	customersStr := []string{""}
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	locations, _ := fetchLocations(db)
+	locationsStr := []string{""}
+	locationsMap := make(map[string]int)
+	locationNames := make(map[int]string)
+	for _, location := range locations {
+		locationsStr = append(locationsStr, location.name)
+		locationsMap[location.name] = location.id
+		locationNames[location.id] = location.name
+	}
+
+	nameInput := widget.NewEntry()
+	nameInput.SetText(d.name)
+	typeSelector := widget.NewSelect(reportTypes, func(s string) {})
+	typeSelector.SetSelected(d.reportType)
+	stockIDInput := widget.NewEntry()
+	stockIDInput.SetText(d.stockID)
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	customerSelector.SetSelected(d.customerName)
+	locationSelector := widget.NewSelect(locationsStr, func(s string) {})
+	locationSelector.SetSelected(locationNames[d.locationID])
+	materialTypeSelector := widget.NewSelect(append([]string{""}, materialTypes...), func(s string) {})
+	materialTypeSelector.SetSelected(d.materialType)
+	ownerSelector := widget.NewSelect(append([]string{""}, owners...), func(s string) {})
+	ownerSelector.SetSelected(d.owner)
+	rangeSelector := widget.NewSelect(append([]string{noDateRange}, quickRanges...), func(s string) {})
+	rangeSelector.SetSelected(noDateRange)
+	if d.dateRange != "" {
+		rangeSelector.SetSelected(d.dateRange)
+	}
+	formatSelector := widget.NewSelect([]string{csvFormat, xlsxFormat, pdfFormat}, func(s string) {})
+	formatSelector.SetSelected(d.format)
+	scheduleInput := widget.NewEntry()
+	scheduleInput.SetPlaceHolder("e.g. 0 6 1 * * (empty to run on demand)")
+	scheduleInput.SetText(d.schedule)
+	emailInput := widget.NewEntry()
+	emailInput.SetPlaceHolder("Comma separated addresses")
+	emailInput.SetText(d.email)
+
+	title := "New Saved Report"
+	if d.id != 0 {
+		title = "Edit Saved Report"
+	}
+
+	dialog := dialog.NewForm(title, "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Name *", nameInput),
+			widget.NewFormItem("Report *", typeSelector),
+			widget.NewFormItem("Stock ID", stockIDInput),
+			widget.NewFormItem("Customer", customerSelector),
+			widget.NewFormItem("Location", locationSelector),
+			widget.NewFormItem("Material Type", materialTypeSelector),
+			widget.NewFormItem("Owner", ownerSelector),
+			widget.NewFormItem("Date Range", rangeSelector),
+			widget.NewFormItem("Format *", formatSelector),
+			widget.NewFormItem("Schedule (cron)", scheduleInput),
+			widget.NewFormItem("Email to", emailInput),
+		}, func(confirm bool) {
+			if confirm {
+				d.name = strings.TrimSpace(nameInput.Text)
+				d.reportType = typeSelector.Selected
+				d.stockID = strings.TrimSpace(stockIDInput.Text)
+				d.customerID = customersMap[customerSelector.Selected]
+				d.customerName = customerSelector.Selected
+				d.locationID = locationsMap[locationSelector.Selected]
+				d.materialType = materialTypeSelector.Selected
+				d.owner = ownerSelector.Selected
+				d.dateRange = rangeSelector.Selected
+				if d.dateRange == noDateRange {
+					d.dateRange = ""
+				}
+				d.format = formatSelector.Selected
+				d.schedule = strings.TrimSpace(scheduleInput.Text)
+				d.email = strings.TrimSpace(emailInput.Text)
+
+				if err := d.validate(); err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+
+				if err := saveReportDefinition(db, d); err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+					return
+				}
+
+				onSaved()
+			}
+		}, window)
+
+	dialog.Resize(fyne.NewSize(600, 650))
+	dialog.Show()
+}
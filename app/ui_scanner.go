@@ -0,0 +1,500 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	scanAccept = "Accept"
+	scanUse    = "Use"
+	scanMove   = "Move"
+)
+
+var scanModes = []string{scanAccept, scanUse, scanMove}
+
+const (
+	stepLocation = iota
+	stepMaterial
+	stepQuantity
+	stepDestination
+)
+
+// Material of a scanned label: the item and the owner, or a plain stock ID
+type StockCode struct {
+	itemID  int
+	stockID string
+	owner   string
+}
+
+// Material found by a scanned label in a location
+type ScannedMaterial struct {
+	materialID   int
+	stockID      string
+	customerName string
+	owner        string
+	quantity     int
+}
+
+// Scan-driven receiving, usage and moves: a location label, a material label
+// and a quantity complete a transaction, a move ends with the destination label
+type ScannerSession struct {
+	db   *sql.DB
+	mode string
+	step int
+
+	location    Location
+	material    ScannedMaterial
+	incoming    IncomingMaterial
+	units       []ItemUnit
+	quantity    int
+	canvas      fyne.Canvas
+	input       *widget.Entry
+	jobTicket   *widget.Entry
+	promptLabel *widget.Label
+	detailLabel *widget.Label
+	statusLabel *widget.Label
+}
+
+func showScanner(app fyne.App, db *sql.DB) {
+	window := app.NewWindow("Scanner Mode")
+
+	s := &ScannerSession{
+		db:          db,
+		mode:        scanAccept,
+		canvas:      window.Canvas(),
+		input:       widget.NewEntry(),
+		jobTicket:   widget.NewEntry(),
+		promptLabel: widget.NewLabel(""),
+		detailLabel: widget.NewLabel(""),
+		statusLabel: widget.NewLabel(""),
+	}
+	s.promptLabel.TextStyle.Bold = true
+	s.statusLabel.TextStyle.Bold = true
+	s.statusLabel.Wrapping = fyne.TextWrapWord
+	s.input.SetPlaceHolder("Scan or type, then press Enter")
+	s.input.OnSubmitted = s.handle
+	s.jobTicket.SetPlaceHolder("Job ticket of the usage")
+
+	modeSelector := widget.NewRadioGroup(scanModes, func(mode string) {
+		if mode != "" {
+			s.mode = mode
+		}
+		s.reset()
+	})
+	modeSelector.Horizontal = true
+	modeSelector.Required = true
+	modeSelector.SetSelected(scanAccept)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Mode", modeSelector),
+		widget.NewFormItem("Job Ticket", s.jobTicket),
+	)
+
+	content := container.NewVBox(
+		form,
+		widget.NewSeparator(),
+		s.promptLabel,
+		s.input,
+		s.detailLabel,
+		widget.NewSeparator(),
+		s.statusLabel,
+		widget.NewButton("Start Over", s.reset),
+	)
+
+	window.SetContent(content)
+	window.Resize(fyne.NewSize(600, 400))
+	window.Show()
+
+	s.reset()
+}
+
+func (s *ScannerSession) reset() {
+	s.step = stepLocation
+	s.location = Location{}
+	s.material = ScannedMaterial{}
+	s.incoming = IncomingMaterial{}
+	s.units = nil
+	s.quantity = 0
+	s.detailLabel.SetText("")
+	s.showPrompt()
+}
+
+func (s *ScannerSession) showPrompt() {
+	switch s.step {
+	case stepLocation:
+		if s.mode == scanMove {
+			s.promptLabel.SetText("1. Scan the location to move from")
+		} else {
+			s.promptLabel.SetText("1. Scan the location label")
+		}
+	case stepMaterial:
+		s.promptLabel.SetText("2. Scan the material label")
+	case stepQuantity:
+		if len(s.units) > 1 {
+			s.promptLabel.SetText("3. Enter the quantity, e.g. 250 or 2 " + s.units[len(s.units)-1].unit)
+		} else {
+			s.promptLabel.SetText("3. Enter the quantity")
+		}
+	case stepDestination:
+		s.promptLabel.SetText("4. Scan the location to move to")
+	}
+
+	s.input.SetText("")
+	s.canvas.Focus(s.input)
+}
+
+func (s *ScannerSession) handle(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	var err error
+	switch s.step {
+	case stepLocation:
+		err = s.scanLocation(text)
+	case stepMaterial:
+		err = s.scanMaterial(text)
+	case stepQuantity:
+		err = s.enterQuantity(text)
+	case stepDestination:
+		err = s.scanDestination(text)
+	}
+
+	if err != nil {
+		s.showError(err.Error())
+		s.input.SetText("")
+		s.canvas.Focus(s.input)
+	}
+}
+
+func (s *ScannerSession) scanLocation(code string) error {
+	location, err := resolveLocation(s.db, code)
+	if err != nil {
+		return err
+	}
+
+	s.location = location
+	s.step = stepMaterial
+	s.detailLabel.SetText("Location: " + location.name)
+	s.showPrompt()
+	beep(true)
+
+	return nil
+}
+
+func (s *ScannerSession) scanMaterial(code string) error {
+	stockCode := parseStockCode(code)
+
+	if s.mode == scanAccept {
+		incoming, err := resolveIncoming(s.db, stockCode)
+		if err != nil {
+			return err
+		}
+		if ok, err := isLocationAvailable(s.db, s.location.id, incoming.CustomerName, incoming.StockID); err != nil || !ok {
+			return errors.New(s.location.name + " is occupied by another material")
+		}
+
+		s.incoming = incoming
+		s.units, _ = fetchStockUnits(s.db, incoming.CustomerName, incoming.StockID)
+		s.detailLabel.SetText(fmt.Sprintf("Location: %s\nShipment: %s, %s, %s, quantity %d",
+			s.location.name, incoming.CustomerName, incoming.StockID, incoming.Owner, incoming.Quantity))
+	} else {
+		material, err := resolveMaterial(s.db, s.location.id, stockCode)
+		if err != nil {
+			return err
+		}
+
+		s.material = material
+		s.units, _ = fetchStockUnits(s.db, material.customerName, material.stockID)
+		s.detailLabel.SetText(fmt.Sprintf("Location: %s\nMaterial: %s, %s, %s, on hand %d",
+			s.location.name, material.customerName, material.stockID, material.owner, material.quantity))
+	}
+
+	s.step = stepQuantity
+	s.showPrompt()
+	beep(true)
+
+	return nil
+}
+
+func (s *ScannerSession) enterQuantity(text string) error {
+	quantity, err := parseUnitQuantity(text, s.units)
+	if err != nil {
+		return err
+	}
+
+	switch s.mode {
+	case scanAccept:
+		materialOpts := MaterialOpts{
+			shippingId:   s.incoming.ShippingID,
+			customerName: s.incoming.CustomerName,
+			stockID:      s.incoming.StockID,
+			quantity:     s.incoming.Quantity,
+			maxQty:       s.incoming.MaxQty,
+			minQty:       s.incoming.MinQty,
+			cost:         s.incoming.Cost,
+			materialType: s.incoming.MaterialType,
+			isActive:     s.incoming.IsActive,
+			notes:        s.incoming.Notes,
+			owner:        s.incoming.Owner,
+		}
+		if err := acceptStock(s.db, &materialOpts, s.location.id, quantity, "Scanned"); err != nil {
+			return err
+		}
+		s.showSuccess(fmt.Sprintf("Accepted %d of %s to %s", quantity, s.incoming.StockID, s.location.name))
+	case scanUse:
+		jobTicket := strings.TrimSpace(s.jobTicket.Text)
+		if jobTicket == "" {
+			return errors.New("Enter the job ticket before the quantity")
+		}
+		remaining, _, err := useStock(s.db, s.material.materialID, quantity, jobTicket, "Scanned")
+		if err != nil {
+			return err
+		}
+		s.showSuccess(fmt.Sprintf("Used %d of %s from %s, remaining %d",
+			quantity, s.material.stockID, s.location.name, remaining))
+	case scanMove:
+		if quantity > s.material.quantity {
+			return fmt.Errorf("The moving quantity (%d) is more than the actual one (%d)", quantity, s.material.quantity)
+		}
+		s.quantity = quantity
+		s.step = stepDestination
+		s.showPrompt()
+		beep(true)
+	}
+
+	return nil
+}
+
+func (s *ScannerSession) scanDestination(code string) error {
+	destination, err := resolveLocation(s.db, code)
+	if err != nil {
+		return err
+	}
+	if destination.id == s.location.id {
+		return errors.New("The material is already in " + destination.name)
+	}
+	if ok, err := isLocationAvailable(s.db, destination.id, s.material.customerName, s.material.stockID); err != nil || !ok {
+		return errors.New(destination.name + " is occupied by another material")
+	}
+
+	if err := moveStock(s.db, s.material.materialID, destination.id, s.quantity, "Scanned"); err != nil {
+		return err
+	}
+	s.showSuccess(fmt.Sprintf("Moved %d of %s from %s to %s",
+		s.quantity, s.material.stockID, s.location.name, destination.name))
+
+	return nil
+}
+
+// Confirm the transaction and wait for the next location
+func (s *ScannerSession) showSuccess(message string) {
+	s.statusLabel.Importance = widget.SuccessImportance
+	s.statusLabel.SetText("OK: " + message)
+	beep(true)
+	s.reset()
+}
+
+func (s *ScannerSession) showError(message string) {
+	s.statusLabel.Importance = widget.DangerImportance
+	s.statusLabel.SetText("Error: " + message)
+	beep(false)
+}
+
+// Find a location by its label code or its name
+func resolveLocation(db *sql.DB, code string) (Location, error) {
+	var location Location
+
+	var rows *sql.Rows
+	var err error
+	if id, errID := strconv.Atoi(strings.TrimPrefix(code, locationPrefix)); errID == nil && strings.HasPrefix(code, locationPrefix) {
+		rows, err = db.Query(`SELECT location_id, name, warehouse_id FROM locations WHERE location_id = $1;`, id)
+	} else {
+		rows, err = db.Query(`SELECT location_id, name, warehouse_id FROM locations WHERE name = $1;`, code)
+	}
+	if err != nil {
+		log.Println("Error resolveLocation1: ", err)
+		return location, err
+	}
+	defer rows.Close()
+
+	var found int
+	for rows.Next() {
+		if err := rows.Scan(&location.id, &location.name, &location.warehouseID); err != nil {
+			log.Println("Error resolveLocation2: ", err)
+			return location, err
+		}
+		found++
+	}
+
+	switch {
+	case found == 0:
+		return location, errors.New("Unknown location \"" + code + "\"")
+	case found > 1:
+		return location, errors.New("Several warehouses have the location \"" + code + "\", scan its label")
+	}
+
+	return location, nil
+}
+
+// The item and the owner of a material label, a plain stock ID is also accepted
+func parseStockCode(code string) StockCode {
+	if !strings.HasPrefix(code, stockPrefix) {
+		return StockCode{stockID: code}
+	}
+
+	// A stock ID may start like a label code as well
+	itemText, owner, _ := strings.Cut(strings.TrimPrefix(code, stockPrefix), "-")
+	itemID, err := strconv.Atoi(itemText)
+	if err != nil || (owner != "Tag" && owner != "Customer") {
+		return StockCode{stockID: code}
+	}
+
+	return StockCode{itemID: itemID, owner: owner}
+}
+
+// The material of a label in a location
+func resolveMaterial(db *sql.DB, locationID int, stockCode StockCode) (ScannedMaterial, error) {
+	var material ScannedMaterial
+
+	rows, err := db.Query(`
+		SELECT m.material_id, m.stock_id, COALESCE(c.name, ''), m.owner, m.quantity
+		FROM materials m
+		LEFT JOIN customers c ON c.customer_id = m.customer_id
+		WHERE m.location_id = $1 AND m.quantity > 0 AND
+			($2 = 0 OR m.item_id = $2) AND
+			($3 = '' OR m.stock_id = $3) AND
+			($4 = '' OR m.owner::TEXT = $4);`,
+		locationID, stockCode.itemID, stockCode.stockID, stockCode.owner)
+	if err != nil {
+		log.Println("Error resolveMaterial1: ", err)
+		return material, err
+	}
+	defer rows.Close()
+
+	var found int
+	for rows.Next() {
+		if err := rows.Scan(&material.materialID, &material.stockID, &material.customerName,
+			&material.owner, &material.quantity); err != nil {
+			log.Println("Error resolveMaterial2: ", err)
+			return material, err
+		}
+		found++
+	}
+
+	switch {
+	case found == 0:
+		return material, errors.New("The scanned material is not found in the location")
+	case found > 1:
+		return material, errors.New("The location has " + material.stockID + " of several customers or owners, scan the material label")
+	}
+
+	return material, nil
+}
+
+// The oldest incoming shipment of a material label
+func resolveIncoming(db *sql.DB, stockCode StockCode) (IncomingMaterial, error) {
+	var material IncomingMaterial
+
+	err := db.QueryRow(`
+		SELECT im.shipping_id, im.customer_name, im.stock_id, im.cost, im.quantity,
+			   COALESCE(im.min_required_quantity, 0), COALESCE(im.max_required_quantity, 0),
+			   COALESCE(im.notes, ''), im.is_active, im.type, im.owner, im.created_at
+		FROM incoming_materials im
+		LEFT JOIN items i ON i.item_id = $1
+		LEFT JOIN customers c ON c.customer_id = i.customer_id
+		WHERE
+			($1 = 0 AND im.stock_id = $2 OR im.stock_id = i.stock_id AND im.customer_name = c.name) AND
+			($3 = '' OR im.owner::TEXT = $3)
+		ORDER BY im.created_at, im.shipping_id
+		LIMIT 1;`,
+		stockCode.itemID, stockCode.stockID, stockCode.owner,
+	).Scan(
+		&material.ShippingID,
+		&material.CustomerName,
+		&material.StockID,
+		&material.Cost,
+		&material.Quantity,
+		&material.MinQty,
+		&material.MaxQty,
+		&material.Notes,
+		&material.IsActive,
+		&material.MaterialType,
+		&material.Owner,
+		&material.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return material, errors.New("No incoming shipment of the scanned material")
+	}
+	if err != nil {
+		log.Println("Error resolveIncoming: ", err)
+	}
+
+	return material, err
+}
+
+// A location is available when it is empty or has the same material of the customer
+func isLocationAvailable(db *sql.DB, locationID int, customerName string, stockID string) (bool, error) {
+	var available bool
+
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM locations l
+			LEFT JOIN materials m ON m.location_id = l.location_id AND m.quantity > 0
+			LEFT JOIN customers c ON c.customer_id = m.customer_id
+			WHERE l.location_id = $1 AND
+				((c.name = $2 AND m.stock_id = $3) OR m.material_id IS NULL)
+		);`, locationID, customerName, stockID).Scan(&available)
+	if err != nil {
+		log.Println("Error isLocationAvailable: ", err)
+	}
+
+	return available, err
+}
+
+// Audible confirmation with the sounds of the system,
+// the terminal bell when they cannot be played
+func beep(success bool) {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "windows":
+		tone := "[console]::beep(1000,150)"
+		if !success {
+			tone = "[console]::beep(300,400)"
+		}
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", tone)
+	case "darwin":
+		sound := "/System/Library/Sounds/Glass.aiff"
+		if !success {
+			sound = "/System/Library/Sounds/Basso.aiff"
+		}
+		cmd = exec.Command("afplay", sound)
+	default:
+		sound := "/usr/share/sounds/freedesktop/stereo/complete.oga"
+		if !success {
+			sound = "/usr/share/sounds/freedesktop/stereo/dialog-error.oga"
+		}
+		cmd = exec.Command("paplay", sound)
+	}
+
+	go func() {
+		if err := cmd.Run(); err != nil {
+			fmt.Fprint(os.Stdout, "\a")
+		}
+	}()
+}
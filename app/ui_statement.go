@@ -0,0 +1,350 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+type StatementReport struct {
+	Report
+	stmFilter SearchFilter
+}
+
+type StatementLine struct {
+	qty   int
+	value decimal.Decimal
+}
+
+// Roll-forward of a stock ID for the statement period
+type StockStatement struct {
+	stockID      string
+	materialType string
+	unit         ItemUnit // of the quantities
+	opening      StatementLine
+	receipts     StatementLine
+	usage        map[string]*StatementLine // by job ticket
+	jobTickets   []string
+	movesOut     StatementLine
+	movesIn      StatementLine
+	ownershipOut StatementLine // sold to the other owner
+	ownershipIn  StatementLine // bought from the other owner at the transfer price
+	adjustments  StatementLine
+}
+
+func (s StatementReport) getReportList() [][]string {
+	rows, err := s.db.Query(`
+	SELECT b.stock_id,
+		   b.material_type::TEXT,
+		   '' AS transaction_type,
+		   '' AS job_ticket,
+		   TRUE AS is_opening,
+		   FALSE AS is_incoming,
+		   SUM(b.quantity) AS "quantity",
+		   SUM(b.value) AS "total_value"
+	FROM material_balances($2::timestamp) b
+	WHERE b.customer_id = $1 AND b.material_type IS NOT NULL
+	GROUP BY 1, 2
+	HAVING SUM(b.quantity) <> 0
+	UNION ALL
+	SELECT tl.stock_id,
+		   COALESCE(tl.material_type::TEXT, ''),
+		   tl.transaction_type::TEXT,
+		   COALESCE(tl.job_ticket, ''),
+		   FALSE,
+		   tl.quantity_change > 0,
+		   SUM(tl.quantity_change),
+		   SUM(tl.quantity_change * tl.cost)
+	FROM transactions_log tl
+	WHERE
+		tl.customer_id = $1 AND
+		tl.updated_at >= $2::timestamp AND
+		tl.updated_at < $3::timestamp
+	GROUP BY 1, 2, 3, 4, 5, 6
+	ORDER BY 1, 4;`,
+		s.stmFilter.customerID, s.stmFilter.dateFrom, getDayAfter(s.stmFilter.dateTo),
+	)
+	if err != nil {
+		log.Println("Error getStatementTable1: ", err)
+	}
+
+	stmList := [][]string{
+		{
+			"Stock ID", "Material Type", "Line", "Job Ticket", "Unit", "Quantity", "Value, USD",
+		},
+	}
+
+	if rows == nil {
+		return stmList
+	}
+	defer rows.Close()
+
+	units, _ := fetchCustomerReportUnits(s.db, s.stmFilter.customerID, s.stmFilter.unit)
+
+	var stocks []*StockStatement
+	stocksMap := make(map[string]*StockStatement)
+
+	for rows.Next() {
+		var stockID, materialType, trxType, jobTicket string
+		var isOpening, isIncoming bool
+		var line StatementLine
+
+		err := rows.Scan(&stockID, &materialType, &trxType, &jobTicket,
+			&isOpening, &isIncoming, &line.qty, &line.value)
+		if err != nil {
+			log.Println("Error getStatementTable2: ", err)
+			continue
+		}
+
+		stock, ok := stocksMap[stockID+"|"+materialType]
+		if !ok {
+			unit, ok := units[stockID]
+			if !ok {
+				unit = ItemUnit{unit: defaultItemUnit, factor: 1}
+			}
+			stock = &StockStatement{
+				stockID:      stockID,
+				materialType: materialType,
+				unit:         unit,
+				usage:        make(map[string]*StatementLine),
+			}
+			stocksMap[stockID+"|"+materialType] = stock
+			stocks = append(stocks, stock)
+		}
+
+		if isOpening {
+			stock.opening.add(line)
+			continue
+		}
+
+		switch trxType {
+		case receiptTrx:
+			stock.receipts.add(line)
+		case usageTrx:
+			usage, ok := stock.usage[jobTicket]
+			if !ok {
+				usage = &StatementLine{}
+				stock.usage[jobTicket] = usage
+				stock.jobTickets = append(stock.jobTickets, jobTicket)
+			}
+			usage.add(line)
+		case moveTrx:
+			if isIncoming {
+				stock.movesIn.add(line)
+			} else {
+				stock.movesOut.add(line)
+			}
+		case ownershipTrx:
+			if isIncoming {
+				stock.ownershipIn.add(line)
+			} else {
+				stock.ownershipOut.add(line)
+			}
+		case adjustmentTrx:
+			stock.adjustments.add(line)
+		}
+	}
+
+	for _, stock := range stocks {
+		closing := stock.opening
+		closing.add(stock.receipts)
+		closing.add(stock.movesOut)
+		closing.add(stock.movesIn)
+		closing.add(stock.ownershipOut)
+		closing.add(stock.ownershipIn)
+		closing.add(stock.adjustments)
+
+		stmList = append(stmList, stock.getRow("Opening Balance", "", stock.opening))
+		stmList = append(stmList, stock.getRow("Receipts", "", stock.receipts))
+		for _, jobTicket := range stock.jobTickets {
+			closing.add(*stock.usage[jobTicket])
+			stmList = append(stmList, stock.getRow("Usage", jobTicket, *stock.usage[jobTicket]))
+		}
+		if stock.movesOut.qty != 0 || stock.movesIn.qty != 0 {
+			stmList = append(stmList, stock.getRow("Moved Out", "", stock.movesOut))
+			stmList = append(stmList, stock.getRow("Moved In", "", stock.movesIn))
+		}
+		if stock.ownershipOut.qty != 0 || stock.ownershipIn.qty != 0 {
+			stmList = append(stmList, stock.getRow("Ownership Out", "", stock.ownershipOut))
+			stmList = append(stmList, stock.getRow("Ownership In", "", stock.ownershipIn))
+		}
+		if stock.adjustments.qty != 0 {
+			stmList = append(stmList, stock.getRow("Adjustments", "", stock.adjustments))
+		}
+		stmList = append(stmList, stock.getRow("Closing Balance", "", closing))
+	}
+
+	return stmList
+}
+
+func (l *StatementLine) add(line StatementLine) {
+	l.qty += line.qty
+	l.value = l.value.Add(line.value)
+}
+
+func (s *StockStatement) getRow(name string, jobTicket string, line StatementLine) []string {
+	return []string{
+		s.stockID,
+		s.materialType,
+		name,
+		jobTicket,
+		s.unit.unit,
+		formatQuantityInUnit(line.qty, s.unit.factor),
+		formatMoney(line.value),
+	}
+}
+
+// The lines of a statement are not summed up, so the totals are not shown
+func (s StatementReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, TextColumn, TextColumn, TextColumn, NumberColumn, AmountColumn,
+	}
+}
+
+func (s StatementReport) getReportHeader() ReportHeader {
+	return ReportHeader{
+		title:        "Monthly Statement",
+		customerName: s.stmFilter.customerName,
+		period:       formatPeriod(s.stmFilter.dateFrom, s.stmFilter.dateTo),
+	}
+}
+
+func (s StatementReport) showReport() {
+	customers, _ := fetchCustomers(s.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	dateFromEntry := newDateEntry(s.window)
+	dateToEntry := newDateEntry(s.window)
+	rangeSelector := newQuickRangeSelector(dateFromEntry, dateToEntry)
+	// The previous month by default
+	rangeSelector.SetSelected("Last month")
+	batchChkBox := widget.NewCheck("", func(b bool) {})
+	formatSelector := widget.NewSelect([]string{csvFormat, xlsxFormat, pdfFormat}, func(s string) {})
+	formatSelector.SetSelected(pdfFormat)
+	unitSelector := newReportUnitSelector(s.db)
+
+	dialog := dialog.NewForm("Statement Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Customer", customerSelector),
+			widget.NewFormItem("Period", rangeSelector),
+			widget.NewFormItem("Date From", dateFromEntry),
+			widget.NewFormItem("Date To", dateToEntry),
+			widget.NewFormItem("Unit", unitSelector),
+			widget.NewFormItem("All customers", batchChkBox),
+			widget.NewFormItem("Batch file format", formatSelector),
+		}, func(confirm bool) {
+			if confirm {
+				from, to, err := getDateRange(dateFromEntry, dateToEntry)
+				if err == nil && (from.IsZero() || to.IsZero()) {
+					err = errors.New("Choose both Date From and Date To")
+				}
+				if err != nil {
+					dialog.ShowInformation("Error", err.Error(), s.window)
+					return
+				}
+
+				s.stmFilter = SearchFilter{
+					dateFrom: from,
+					dateTo:   to,
+					unit:     strings.TrimSpace(unitSelector.Text),
+				}
+
+				if batchChkBox.Checked {
+					s.saveStatements(customers, formatSelector.Selected)
+					return
+				}
+
+				if customerSelector.Selected == "" {
+					dialog.ShowInformation("Error", "Choose a customer", s.window)
+					return
+				}
+
+				s.stmFilter.customerID = customersMap[customerSelector.Selected]
+				s.stmFilter.customerName = customerSelector.Selected
+
+				stmList := s.getReportList()
+				showReportWindow(s.app, "Statement: "+customerSelector.Selected, s, stmList,
+					"statement_"+safeFileName(customerSelector.Selected)+"_"+from.Format("2006-01"),
+					fyne.NewSize(1000, 700))
+			}
+		}, s.window)
+
+	dialog.Resize(fyne.NewSize(600, 450))
+	dialog.Show()
+}
+
+// Batch mode: save a statement of every customer with an opening balance
+// or transactions in the period to the chosen folder
+func (s StatementReport) saveStatements(customers []Customer, format string) {
+	folderDialog := dialog.NewFolderOpen(func(folder fyne.ListableURI, err error) {
+		if err != nil {
+			log.Println("Error saveStatements1: ", err)
+			dialog.ShowError(err, s.window)
+			return
+		}
+		if folder == nil {
+			return
+		}
+
+		var saved, failed []string
+
+		for _, customer := range customers {
+			stm := s
+			stm.stmFilter.customerID = customer.id
+			stm.stmFilter.customerName = customer.name
+
+			stmList := stm.getReportList()
+			if len(stmList) == 1 {
+				continue
+			}
+
+			fileName := "statement_" + safeFileName(customer.name) + "_" +
+				stm.stmFilter.dateFrom.Format("2006-01") + "." + format
+
+			if err := saveStatement(folder, fileName, stm, stmList, format); err != nil {
+				log.Println("Error saveStatements2: ", err)
+				failed = append(failed, customer.name+": "+err.Error())
+				continue
+			}
+			saved = append(saved, fileName)
+		}
+
+		message := strconv.Itoa(len(saved)) + " statement(s) have been saved to " + folder.Path()
+		if len(failed) > 0 {
+			message += "\n\nFailed:\n" + strings.Join(failed, "\n")
+		}
+		dialog.ShowInformation("Statements", message, s.window)
+	}, s.window)
+
+	folderDialog.Resize(fyne.NewSize(800, 600))
+	folderDialog.Show()
+}
+
+func saveStatement(folder fyne.ListableURI, fileName string, r Reporter, list [][]string, format string) error {
+	uri, err := storage.Child(folder, fileName)
+	if err != nil {
+		return err
+	}
+
+	writer, err := storage.Writer(uri)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return writeReport(writer, r, list, format)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Quantity entry with the unit selector of the chosen item
+type UnitQuantityInput struct {
+	quantity *widget.Entry
+	unit     *widget.Select
+	units    []ItemUnit
+}
+
+func newUnitQuantityInput() *UnitQuantityInput {
+	return &UnitQuantityInput{
+		quantity: widget.NewEntry(),
+		unit:     widget.NewSelect([]string{}, func(s string) {}),
+	}
+}
+
+// Offer the units of an item, the base unit is selected
+func (q *UnitQuantityInput) setUnits(units []ItemUnit) {
+	q.units = units
+	q.unit.SetOptions(getUnitNames(units))
+	if len(units) > 0 {
+		q.unit.SetSelected(units[0].unit)
+	} else {
+		q.unit.ClearSelected()
+	}
+}
+
+// The quantity in the base unit of the item
+func (q *UnitQuantityInput) getBaseQuantity() (int, error) {
+	return parseUnitQuantity(q.quantity.Text+" "+q.unit.Selected, q.units)
+}
+
+func (q *UnitQuantityInput) getBaseUnit() string {
+	if len(q.units) == 0 {
+		return defaultItemUnit
+	}
+
+	return q.units[0].unit
+}
+
+func (q *UnitQuantityInput) content() fyne.CanvasObject {
+	return container.NewBorder(nil, nil, nil,
+		container.NewGridWrap(fyne.NewSize(150, q.unit.MinSize().Height), q.unit),
+		q.quantity)
+}
+
+// Unit of the report quantities, the base units of the items when it is empty
+func newReportUnitSelector(db *sql.DB) *widget.SelectEntry {
+	unitNames, _ := fetchUnitNames(db)
+	unitSelector := widget.NewSelectEntry(unitNames)
+	unitSelector.SetPlaceHolder("Base units")
+
+	return unitSelector
+}
+
+// Unit rows of the units form
+type unitRow struct {
+	unitInput     *widget.Entry
+	quantityInput *widget.Entry
+	content       fyne.CanvasObject
+}
+
+// Packaging units of an item, the quantity of a unit is entered in the base
+// unit or in a smaller unit of the form, e.g. "40 case" for a pallet
+func editItemUnits(window fyne.Window, db *sql.DB, item Item) {
+	units, err := fetchItemUnits(db, item.id)
+	if err != nil {
+		dialog.ShowInformation("Error", err.Error(), window)
+		return
+	}
+
+	var rows []*unitRow
+	rowsBox := container.NewVBox()
+
+	addRow := func(u ItemUnit) {
+		row := &unitRow{
+			unitInput:     widget.NewEntry(),
+			quantityInput: widget.NewEntry(),
+		}
+		row.unitInput.SetText(u.unit)
+		row.unitInput.SetPlaceHolder("Unit, e.g. case")
+		if u.factor > 0 {
+			row.quantityInput.SetText(strconv.Itoa(u.factor) + " " + item.unit)
+		}
+		row.quantityInput.SetPlaceHolder("e.g. 500 " + item.unit)
+
+		removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+		row.content = container.NewBorder(nil, nil, nil,
+			container.NewHBox(container.NewGridWrap(fyne.NewSize(200, row.quantityInput.MinSize().Height), row.quantityInput), removeButton),
+			row.unitInput)
+		removeButton.OnTapped = func() {
+			for i, r := range rows {
+				if r == row {
+					rows = append(rows[:i], rows[i+1:]...)
+					break
+				}
+			}
+			rowsBox.Remove(row.content)
+		}
+
+		rows = append(rows, row)
+		rowsBox.Add(row.content)
+	}
+
+	for _, u := range units {
+		addRow(u)
+	}
+
+	unitsDialog := dialog.NewForm("Units: "+item.customerName+" / "+item.stockID, "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Base Unit", widget.NewLabel(item.unit)),
+			widget.NewFormItem("Units", container.NewVScroll(rowsBox)),
+			widget.NewFormItem("", widget.NewButton("Add Unit", func() { addRow(ItemUnit{}) })),
+		}, func(confirm bool) {
+			if confirm {
+				// The units are known to the next rows in the order of the form
+				known := []ItemUnit{{unit: item.unit, factor: 1}}
+				var saved []ItemUnit
+				for _, row := range rows {
+					name := strings.TrimSpace(row.unitInput.Text)
+					if name == "" && strings.TrimSpace(row.quantityInput.Text) == "" {
+						continue
+					}
+
+					factor, err := parseUnitQuantity(row.quantityInput.Text, known)
+					if err != nil {
+						dialog.ShowInformation("Error", name+": "+err.Error(), window)
+						return
+					}
+
+					u := ItemUnit{unit: name, factor: factor}
+					known = append(known, u)
+					saved = append(saved, u)
+				}
+
+				if err := saveItemUnits(db, item, saved); err != nil {
+					dialog.ShowInformation("Error", err.Error(), window)
+				}
+			}
+		}, window)
+
+	unitsDialog.Resize(fyne.NewSize(600, 400))
+	unitsDialog.Show()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/shopspring/decimal"
+)
+
+var owners = []string{"Tag", "Customer"}
+
+type ValuationReport struct {
+	Report
+	valFilter SearchFilter
+}
+
+func (v ValuationReport) getReportList() [][]string {
+	rows, err := v.db.Query(`
+	SELECT b.owner,
+		   COALESCE(c.name, ''),
+		   COALESCE(b.material_type::TEXT, ''),
+		   COALESCE(w.name, ''),
+		   COALESCE(u.unit, i.unit, $6),
+		   SUM(b.quantity::float / COALESCE(u.factor, 1)) AS "quantity",
+		   SUM(b.value) AS "total_value"
+	FROM material_balances($4::timestamp) b
+	LEFT JOIN items i ON i.customer_id = b.customer_id AND i.stock_id = b.stock_id
+	LEFT JOIN item_units u ON u.item_id = i.item_id AND u.unit = $5
+	LEFT JOIN customers c ON c.customer_id = b.customer_id
+	LEFT JOIN locations l ON l.location_id = b.location_id
+	LEFT JOIN warehouses w ON w.warehouse_id = l.warehouse_id
+	WHERE
+		b.owner IS NOT NULL AND
+		($1 = '' OR b.owner::TEXT = $1) AND
+		($2 = 0 OR b.customer_id = $2) AND
+		($3 = '' OR b.material_type::TEXT = $3)
+	GROUP BY 1, 2, 3, 4, 5
+	HAVING SUM(b.quantity) <> 0
+	ORDER BY 1, 2, 3, 4, 5;`,
+		v.valFilter.owner, v.valFilter.customerID, v.valFilter.materialType, getDayAfter(v.valFilter.dateAsOf),
+		v.valFilter.unit, defaultItemUnit,
+	)
+	if err != nil {
+		log.Println("Error getValuationTable1: ", err)
+	}
+
+	valList := [][]string{
+		{
+			"Owner", "Customer", "Material Type", "Warehouse", "Unit", "Quantity", "Total Value, USD",
+		},
+	}
+
+	if rows == nil {
+		return valList
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var owner, customerName, materialType, warehouseName, unit string
+		var qty float64
+		var value decimal.Decimal
+
+		err := rows.Scan(&owner, &customerName, &materialType, &warehouseName, &unit, &qty, &value)
+		if err != nil {
+			log.Println("Error getValuationTable2: ", err)
+			continue
+		}
+
+		valList = append(valList, []string{
+			owner,
+			customerName,
+			materialType,
+			warehouseName,
+			unit,
+			formatUnitQuantity(qty),
+			formatMoney(value),
+		})
+	}
+
+	return valList
+}
+
+// The subtotal of an owner is the total of the list filtered by the owner
+func (v ValuationReport) getColumnTypes() []ColumnType {
+	return []ColumnType{
+		TextColumn, TextColumn, TextColumn, TextColumn, TextColumn,
+		getUnitQuantityColumn(v.valFilter.unit), ValueColumn,
+	}
+}
+
+func (v ValuationReport) getReportHeader() ReportHeader {
+	title := "Inventory Valuation by Owner"
+	if v.valFilter.owner != "" {
+		title += " (" + v.valFilter.owner + ")"
+	}
+
+	return ReportHeader{
+		title:        title,
+		customerName: v.valFilter.customerName,
+		period:       "As of " + formatReportDate(v.valFilter.dateAsOf),
+	}
+}
+
+func (v ValuationReport) showReport() {
+	customers, _ := fetchCustomers(v.db)
+	var customersStr []string
+	customersMap := make(map[string]int)
+	for _, customer := range customers {
+		customersStr = append(customersStr, customer.name)
+		customersMap[customer.name] = customer.id
+	}
+
+	ownerSelector := widget.NewSelect(owners, func(s string) {})
+	customerSelector := widget.NewSelect(customersStr, func(s string) {})
+	typeSelector := widget.NewSelect(materialTypes, func(s string) {})
+	dateAsOf := newDateEntry(v.window)
+	dateAsOf.SetDate(time.Now())
+	unitSelector := newReportUnitSelector(v.db)
+
+	dialog := dialog.NewForm("Filter Options", "Show", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Owner", ownerSelector),
+			widget.NewFormItem("Customer", customerSelector),
+			widget.NewFormItem("Material Type", typeSelector),
+			widget.NewFormItem("Date As of", dateAsOf),
+			widget.NewFormItem("Unit", unitSelector),
+		}, func(confirm bool) {
+			if confirm {
+				asOf, err := dateAsOf.GetDate()
+				if err != nil || asOf.IsZero() {
+					dialog.ShowInformation("Error", "The date must be in the "+getDateHint()+" format", v.window)
+					return
+				}
+
+				v.valFilter = SearchFilter{
+					owner:        ownerSelector.Selected,
+					customerID:   customersMap[customerSelector.Selected],
+					customerName: customerSelector.Selected,
+					materialType: typeSelector.Selected,
+					dateAsOf:     asOf,
+					unit:         strings.TrimSpace(unitSelector.Text),
+				}
+
+				valList := v.getReportList()
+				showReportWindow(v.app, "Inventory Valuation", v, valList,
+					"valuation_"+asOf.Format("2006-01-02"), fyne.NewSize(1000, 600))
+			}
+		}, v.window)
+
+	dialog.Resize(fyne.NewSize(500, 300))
+	dialog.Show()
+}
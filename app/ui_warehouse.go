@@ -3,10 +3,13 @@ package main
 import (
 	"database/sql"
 	"log"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -102,3 +105,76 @@ func addWarehouse(myWindow fyne.Window, db *sql.DB) {
 	dialog.Resize(fyne.NewSize(600, 300))
 	dialog.Show()
 }
+
+// Warehouses with their locations and the batch label printing
+func showWarehouses(app fyne.App, db *sql.DB) {
+	window := app.NewWindow("Warehouses")
+
+	var refresh func()
+	refresh = func() {
+		rows, err := db.Query(`
+			SELECT w.warehouse_id, w.name, COUNT(l.location_id)
+			FROM warehouses w
+			LEFT JOIN locations l ON l.warehouse_id = w.warehouse_id
+			GROUP BY w.warehouse_id, w.name
+			ORDER BY w.name;`)
+		if err != nil {
+			log.Println("Error showWarehouses1: ", err)
+			dialog.ShowInformation("Error", err.Error(), window)
+			return
+		}
+		defer rows.Close()
+
+		warehouseWidgets := []fyne.CanvasObject{}
+
+		for rows.Next() {
+			var warehouse Warehouse
+			var locationsNumber int
+			if err := rows.Scan(&warehouse.warehouseId, &warehouse.name, &locationsNumber); err != nil {
+				log.Println("Error showWarehouses2: ", err)
+				continue
+			}
+
+			nameLabel := widget.NewLabel(warehouse.name)
+			nameLabel.TextStyle.Bold = true
+			fileName := safeFileName(warehouse.name)
+
+			warehouseWidgets = append(warehouseWidgets,
+				container.New(layout.NewGridLayoutWithColumns(4),
+					nameLabel,
+					widget.NewLabel("Locations: "+strconv.Itoa(locationsNumber)),
+					widget.NewButton("Location Labels", func() {
+						labels, err := fetchLocationLabels(db, warehouse.warehouseId)
+						if err != nil {
+							dialog.ShowInformation("Error", err.Error(), window)
+							return
+						}
+						printLabels(window, db, labels, "locations_"+fileName)
+					}),
+					widget.NewButton("Material Labels", func() {
+						labels, err := fetchMaterialLabels(db, warehouse.warehouseId)
+						if err != nil {
+							dialog.ShowInformation("Error", err.Error(), window)
+							return
+						}
+						printLabels(window, db, labels, "materials_"+fileName)
+					}),
+				),
+				widget.NewSeparator(),
+			)
+		}
+
+		toolbar := container.New(layout.NewGridLayoutWithColumns(3),
+			widget.NewButton("Add Location", func() { addWarehouse(window, db) }),
+			widget.NewButton("Label Templates", func() { showLabelTemplates(window, db) }),
+			widget.NewButton("Refresh", refresh),
+		)
+
+		window.SetContent(container.NewBorder(toolbar, nil, nil, nil,
+			container.NewVScroll(container.NewVBox(warehouseWidgets...))))
+	}
+
+	refresh()
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// A packaging unit of an item with the quantity of the base unit in it,
+// e.g. 1 case = 500 envelopes. The base unit of the item has the factor 1.
+type ItemUnit struct {
+	unit   string
+	factor int
+}
+
+// Units of an item of a customer stock ID, the base unit first
+func fetchStockUnits(db queryExecutor, customerName string, stockID string) ([]ItemUnit, error) {
+	rows, err := db.Query(`
+		SELECT i.unit, 1
+		FROM items i
+		JOIN customers c ON c.customer_id = i.customer_id
+		WHERE c.name = $1 AND i.stock_id = $2
+		UNION ALL
+		SELECT u.unit, u.factor
+		FROM item_units u
+		JOIN items i ON i.item_id = u.item_id
+		JOIN customers c ON c.customer_id = i.customer_id
+		WHERE c.name = $1 AND i.stock_id = $2
+		ORDER BY 2, 1;`, customerName, stockID)
+	if err != nil {
+		log.Println("Error fetchStockUnits1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []ItemUnit
+
+	for rows.Next() {
+		var u ItemUnit
+		if err := rows.Scan(&u.unit, &u.factor); err != nil {
+			log.Println("Error fetchStockUnits2: ", err)
+			return units, err
+		}
+		units = append(units, u)
+	}
+
+	// Stock IDs without an item are counted in the default unit
+	if len(units) == 0 {
+		units = append(units, ItemUnit{unit: defaultItemUnit, factor: 1})
+	}
+
+	return units, rows.Err()
+}
+
+// Packaging units of an item without the base unit
+func fetchItemUnits(db *sql.DB, itemID int) ([]ItemUnit, error) {
+	rows, err := db.Query(`
+		SELECT unit, factor
+		FROM item_units
+		WHERE item_id = $1
+		ORDER BY factor, unit;`, itemID)
+	if err != nil {
+		log.Println("Error fetchItemUnits1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []ItemUnit
+
+	for rows.Next() {
+		var u ItemUnit
+		if err := rows.Scan(&u.unit, &u.factor); err != nil {
+			log.Println("Error fetchItemUnits2: ", err)
+			return units, err
+		}
+		units = append(units, u)
+	}
+
+	return units, rows.Err()
+}
+
+// Replace the packaging units of an item
+func saveItemUnits(db *sql.DB, item Item, units []ItemUnit) error {
+	names := map[string]bool{strings.ToLower(item.unit): true}
+	for _, u := range units {
+		if strings.TrimSpace(u.unit) == "" || u.factor <= 1 {
+			return errors.New("Every unit needs a name and more than one " + item.unit + " in it")
+		}
+		if names[strings.ToLower(u.unit)] {
+			return errors.New("The unit " + u.unit + " is listed twice")
+		}
+		names[strings.ToLower(u.unit)] = true
+	}
+
+	return withTransaction(db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM item_units WHERE item_id = $1;`, item.id); err != nil {
+			log.Println("Error saveItemUnits1: ", err)
+			return err
+		}
+
+		for _, u := range units {
+			if _, err := tx.Exec(`INSERT INTO item_units (item_id, unit, factor) VALUES ($1, $2, $3);`,
+				item.id, strings.TrimSpace(u.unit), u.factor); err != nil {
+				log.Println("Error saveItemUnits2: ", err)
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Unit of the report quantities of every stock ID of a customer: the packaging
+// unit when the item has it, otherwise the base unit
+func fetchCustomerReportUnits(db *sql.DB, customerID int, unit string) (map[string]ItemUnit, error) {
+	rows, err := db.Query(`
+		SELECT i.stock_id, COALESCE(u.unit, i.unit), COALESCE(u.factor, 1)
+		FROM items i
+		LEFT JOIN item_units u ON u.item_id = i.item_id AND u.unit = $2
+		WHERE i.customer_id = $1;`, customerID, unit)
+	if err != nil {
+		log.Println("Error fetchCustomerReportUnits1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := make(map[string]ItemUnit)
+
+	for rows.Next() {
+		var stockID string
+		var u ItemUnit
+		if err := rows.Scan(&stockID, &u.unit, &u.factor); err != nil {
+			log.Println("Error fetchCustomerReportUnits2: ", err)
+			return units, err
+		}
+		units[stockID] = u
+	}
+
+	return units, rows.Err()
+}
+
+// Names of the packaging units of all items for the report options
+func fetchUnitNames(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT unit FROM item_units ORDER BY unit;`)
+	if err != nil {
+		log.Println("Error fetchUnitNames1: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Println("Error fetchUnitNames2: ", err)
+			return names, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func findUnit(units []ItemUnit, unit string) (ItemUnit, bool) {
+	for _, u := range units {
+		if strings.EqualFold(u.unit, unit) {
+			return u, true
+		}
+	}
+
+	return ItemUnit{}, false
+}
+
+func getUnitNames(units []ItemUnit) []string {
+	var names []string
+	for _, u := range units {
+		names = append(names, u.unit)
+	}
+
+	return names
+}
+
+// Base quantity of a quantity in a unit of the item, a unit
+// the item does not have is an error
+func toBaseQuantity(units []ItemUnit, unit string, quantity int) (int, error) {
+	if unit == "" {
+		return quantity, nil
+	}
+
+	u, ok := findUnit(units, unit)
+	if !ok {
+		return 0, errors.New("Unknown unit " + unit)
+	}
+
+	return quantity * u.factor, nil
+}
+
+// Base quantity of a text like "3 case" or "1,500", the quantity
+// without a unit is in the base unit
+func parseUnitQuantity(text string, units []ItemUnit) (int, error) {
+	fields := strings.Fields(strings.ReplaceAll(text, ",", ""))
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, errors.New("The quantity must be a positive number and a unit, not \"" + text + "\"")
+	}
+
+	quantity, err := strconv.Atoi(fields[0])
+	if err != nil || quantity <= 0 {
+		return 0, errors.New("The quantity must be a positive number, not \"" + text + "\"")
+	}
+	if len(fields) == 1 {
+		return quantity, nil
+	}
+
+	return toBaseQuantity(units, fields[1], quantity)
+}
+
+// A base quantity in a unit of the factor, e.g. 1250 in a case of 500 is 2.5
+func formatQuantityInUnit(quantity int, factor int) string {
+	if factor <= 1 {
+		return strconv.Itoa(quantity)
+	}
+
+	return formatUnitQuantity(float64(quantity) / float64(factor))
+}
+
+// The quantities in the packaging units are not summed up
+func getUnitQuantityColumn(unit string) ColumnType {
+	if unit != "" {
+		return NumberColumn
+	}
+
+	return QuantityColumn
+}
+
+// A base quantity in a unit with up to 3 decimals, e.g. 1250 each is 2.5 case
+func formatUnitQuantity(quantity float64) string {
+	text := strconv.FormatFloat(quantity, 'f', 3, 64)
+	text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	if text == "-0" {
+		return "0"
+	}
+
+	return text
+}
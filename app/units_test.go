@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+var testUnits = []ItemUnit{
+	{unit: "each", factor: 1},
+	{unit: "case", factor: 500},
+	{unit: "pallet", factor: 20000},
+}
+
+func TestParseUnitQuantity(t *testing.T) {
+	tests := []struct {
+		text     string
+		units    []ItemUnit
+		quantity int
+		wantErr  bool
+	}{
+		{"3 case", testUnits, 1500, false},
+		{"3 CASE", testUnits, 1500, false},
+		{"1 pallet", testUnits, 20000, false},
+		{"1,500", testUnits, 1500, false},
+		{"1,500 each", testUnits, 1500, false},
+		{"  250  ", nil, 250, false},
+		{"2 box", testUnits, 0, true},
+		{"0", testUnits, 0, true},
+		{"0 case", testUnits, 0, true},
+		{"-3 case", testUnits, 0, true},
+		{"", testUnits, 0, true},
+		{"case", testUnits, 0, true},
+		{"3 case extra", testUnits, 0, true},
+		{"1.5 case", testUnits, 0, true},
+	}
+
+	for _, tt := range tests {
+		quantity, err := parseUnitQuantity(tt.text, tt.units)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseUnitQuantity(%q) error = %v, want error %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if quantity != tt.quantity {
+			t.Errorf("parseUnitQuantity(%q) = %d, want %d", tt.text, quantity, tt.quantity)
+		}
+	}
+}
+
+func TestToBaseQuantity(t *testing.T) {
+	tests := []struct {
+		units    []ItemUnit
+		unit     string
+		quantity int
+		want     int
+		wantErr  bool
+	}{
+		{testUnits, "case", 3, 1500, false},
+		{testUnits, "Pallet", 2, 40000, false},
+		{testUnits, "each", 7, 7, false},
+		{testUnits, "", 7, 7, false},
+		{nil, "", 7, 7, false},
+		{nil, "case", 7, 0, true},
+		{testUnits, "case", 0, 0, false},
+		{testUnits, "box", 3, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := toBaseQuantity(tt.units, tt.unit, tt.quantity)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("toBaseQuantity(%q, %d) error = %v, want error %v", tt.unit, tt.quantity, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("toBaseQuantity(%q, %d) = %d, want %d", tt.unit, tt.quantity, got, tt.want)
+		}
+	}
+}
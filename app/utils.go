@@ -1,6 +1,9 @@
 package main
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 func padStart(str string, targetLen int, padChar rune) string {
 	if len(str) >= targetLen {
@@ -10,3 +13,35 @@ func padStart(str string, targetLen int, padChar rune) string {
 	padding := strings.Repeat(string(padChar), targetLen-len(str))
 	return padding + str
 }
+
+// Parse a date typed by the user in the order of the system locale,
+// with slashes, dots or dashes, or in the ISO format
+func parseDate(date string) (time.Time, error) {
+	date = strings.TrimSpace(date)
+
+	layouts := []string{"1/2/2006", "1.2.2006", "1-2-2006"}
+	if dateLayout != "01/02/2006" {
+		layouts = []string{"2/1/2006", "2.1.2006", "2-1-2006"}
+	}
+	layouts = append(layouts, "2006-01-02")
+
+	var err error
+	for _, layout := range layouts {
+		var parsedDate time.Time
+		if parsedDate, err = time.ParseInLocation(layout, date, time.Local); err == nil {
+			return parsedDate, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// Replace the characters that are not allowed in file names
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+}
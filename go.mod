@@ -4,13 +4,24 @@ go 1.23.1
 
 require (
 	fyne.io/fyne/v2 v2.5.1
+	github.com/boombuler/barcode v1.0.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/leekchan/accounting v1.0.0
+	github.com/robfig/cron/v3 v3.0.1
+	github.com/shopspring/decimal v0.0.0-20180709203117-cd690d0c9e24
+	github.com/xuri/excelize/v2 v2.8.1
 )
 
 require (
 	github.com/cockroachdb/apd v1.1.0 // indirect
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/shopspring/decimal v0.0.0-20180709203117-cd690d0c9e24 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.3 // indirect
+	github.com/ruudk/golang-pdf417 v0.0.0-20181029194003-1af4ab5afa58 // indirect
+	github.com/xuri/efp v0.0.0-20231025114914-d1ff6096ae53 // indirect
+	github.com/xuri/nfp v0.0.0-20230919160717-d98342af3f05 // indirect
+	golang.org/x/crypto v0.23.0 // indirect
 )
 
 require (
@@ -21,24 +21,49 @@ CREATE TABLE locations (
 CREATE TYPE material_type AS ENUM ('Carrier','Card','Envelope','Insert', 'Consumables');
 CREATE TYPE owner AS ENUM('Tag', 'Customer')
 
+-- Catalog of the customer stock IDs
+CREATE TABLE items (
+	item_id serial PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	stock_id VARCHAR(100) NOT NULL,
+	material_type MATERIAL_TYPE NOT NULL,
+	description TEXT,
+	unit VARCHAR(20) NOT NULL DEFAULT 'each',
+	min_required_quantity int,
+	max_required_quantity int,
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	default_owner OWNER NOT NULL DEFAULT 'Customer',
+	CONSTRAINT items_customer_stock UNIQUE (customer_id, stock_id),
+	CONSTRAINT items_identity UNIQUE (item_id, customer_id, stock_id)
+);
+
+-- Packaging units of an item, the factor is the quantity of the base unit in one unit
+CREATE TABLE item_units (
+	item_id int NOT NULL REFERENCES items(item_id) ON DELETE CASCADE,
+	unit VARCHAR(20) NOT NULL,
+	factor int NOT NULL CHECK (factor > 1),
+	PRIMARY KEY (item_id, unit)
+);
+
+-- A stock row is the quantity of an item of a customer in a location
 CREATE TABLE materials (
-	material_id serial,
+	material_id serial PRIMARY KEY,
+	item_id int NOT NULL,
 	stock_id VARCHAR(100)  NOT NULL,
 	location_id int REFERENCES locations(location_id),
-	customer_id int REFERENCES customers(customer_id),
-	material_type MATERIAL_TYPE  NOT NULL,
-	description TEXT,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
 	notes TEXT,
 	quantity int  NOT NULL,
 	cost DECIMAL NOT NULL,
-	min_required_quantity int,
-	max_required_quantity int,
 	updated_at TIMESTAMP,
-	is_active BOOLEAN NOT NULL,
 	owner OWNER NOT NULL,
-	CONSTRAINT pk_location_stock_owner PRIMARY KEY (stock_id, location_id, owner)
+	CONSTRAINT materials_item_location_owner UNIQUE (item_id, location_id, owner),
+	CONSTRAINT materials_item_identity FOREIGN KEY (item_id, customer_id, stock_id)
+		REFERENCES items (item_id, customer_id, stock_id)
 );
 
+CREATE TYPE transaction_type AS ENUM('Receipt', 'Usage', 'Move', 'Adjustment', 'Ownership');
+
 CREATE TABLE transactions_log (
 	transaction_id serial PRIMARY KEY,
 	material_id int NOT NULL,
@@ -48,7 +73,34 @@ CREATE TABLE transactions_log (
 	cost DECIMAL,
 	job_ticket VARCHAR(100),
 	updated_at timestamp,
-	remaining_quantity int
+	remaining_quantity int,
+	transaction_type TRANSACTION_TYPE NOT NULL DEFAULT 'Receipt',
+	location_id int REFERENCES locations(location_id),
+	warehouse_id int REFERENCES warehouses(warehouse_id),
+	customer_id int REFERENCES customers(customer_id),
+	owner OWNER,
+	material_type MATERIAL_TYPE,
+	from_location_id int REFERENCES locations(location_id),
+	to_location_id int REFERENCES locations(location_id)
+);
+
+-- Sales of stock between the customer and Tag at an agreed price per unit,
+-- the book value is the FIFO cost of the cost layers of the previous owner
+CREATE TABLE ownership_transfers (
+	transfer_id serial PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	item_id int NOT NULL REFERENCES items(item_id),
+	stock_id VARCHAR(100) NOT NULL,
+	location_id int REFERENCES locations(location_id),
+	from_material_id int NOT NULL REFERENCES materials(material_id),
+	to_material_id int NOT NULL REFERENCES materials(material_id),
+	from_owner OWNER NOT NULL,
+	to_owner OWNER NOT NULL CHECK (to_owner <> from_owner),
+	quantity int NOT NULL CHECK (quantity > 0),
+	transfer_price DECIMAL NOT NULL CHECK (transfer_price >= 0),
+	book_value DECIMAL NOT NULL,
+	notes TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
 CREATE TABLE incoming_materials (
@@ -62,5 +114,207 @@ CREATE TABLE incoming_materials (
 	notes VARCHAR(100),
 	is_active BOOLEAN NOT NULL,
 	type VARCHAR(100) NOT NULL,
-	owner OWNER NOT NULL
-);
\ No newline at end of file
+	owner OWNER NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Notify the open windows of all workstations about the stock changes
+CREATE OR REPLACE FUNCTION notify_inventory_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('inventory_changes', TG_TABLE_NAME);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER materials_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON materials
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
+
+CREATE TRIGGER incoming_materials_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON incoming_materials
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
+
+CREATE TRIGGER transactions_log_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON transactions_log
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
+
+CREATE TABLE report_definitions (
+	definition_id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL UNIQUE,
+	report_type VARCHAR(100) NOT NULL,
+	stock_id VARCHAR(100),
+	customer_id int REFERENCES customers(customer_id),
+	location_id int REFERENCES locations(location_id),
+	material_type VARCHAR(100),
+	owner VARCHAR(100),
+	date_range VARCHAR(100),
+	output_format VARCHAR(10) NOT NULL,
+	schedule VARCHAR(100),
+	email TEXT,
+	last_run_at TIMESTAMP
+);
+
+CREATE TABLE label_templates (
+	template_id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL UNIQUE,
+	width_mm DECIMAL NOT NULL,
+	height_mm DECIMAL NOT NULL,
+	barcode_type VARCHAR(20) NOT NULL,
+	font_size DECIMAL NOT NULL
+);
+
+INSERT INTO label_templates (name, width_mm, height_mm, barcode_type, font_size) VALUES
+	('4x2 in Code128', 101.6, 50.8, 'Code128', 14),
+	('4x2 in QR', 101.6, 50.8, 'QR', 14),
+	('2x1 in Code128', 50.8, 25.4, 'Code128', 8);
+
+-- Kits (bills of materials) of the customer products issued by the job tickets
+CREATE TABLE kits (
+	kit_id SERIAL PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	name VARCHAR(100) NOT NULL,
+	description TEXT,
+	CONSTRAINT kits_customer_name UNIQUE(customer_id, name)
+);
+
+CREATE TABLE kit_components (
+	kit_id int NOT NULL REFERENCES kits(kit_id) ON DELETE CASCADE,
+	stock_id VARCHAR(100) NOT NULL,
+	quantity_per_unit int NOT NULL CHECK (quantity_per_unit > 0),
+	PRIMARY KEY (kit_id, stock_id)
+);
+
+-- Quantities of the customer materials reserved for the upcoming jobs
+CREATE TABLE reservations (
+	reservation_id SERIAL PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	stock_id VARCHAR(100) NOT NULL,
+	job_ticket VARCHAR(100) NOT NULL,
+	quantity int NOT NULL CHECK (quantity > 0),
+	consumed_quantity int NOT NULL DEFAULT 0,
+	required_date DATE,
+	notes TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER reservations_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON reservations
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
+
+-- Job tickets of the material usage
+CREATE TABLE jobs (
+	job_id SERIAL PRIMARY KEY,
+	job_ticket VARCHAR(100) NOT NULL UNIQUE,
+	customer_id int REFERENCES customers(customer_id),
+	description TEXT,
+	status VARCHAR(20) NOT NULL DEFAULT 'Open' CHECK (status IN ('Open', 'Closed')),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	due_date DATE,
+	closed_at TIMESTAMP
+);
+
+-- Closed jobs are locked against further postings
+CREATE OR REPLACE FUNCTION check_job_open() RETURNS trigger AS $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM jobs WHERE job_ticket = NEW.job_ticket AND status = 'Closed') THEN
+		RAISE EXCEPTION 'job % is closed', NEW.job_ticket;
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_log_check_job
+	BEFORE INSERT ON transactions_log
+	FOR EACH ROW WHEN (NEW.job_ticket IS NOT NULL AND NEW.job_ticket <> '')
+	EXECUTE FUNCTION check_job_open();
+
+-- Storage and handling rates of the customer owned materials
+CREATE TABLE rate_cards (
+	customer_id int PRIMARY KEY REFERENCES customers(customer_id),
+	location_day_rate DECIMAL NOT NULL DEFAULT 0,
+	unit_month_rate DECIMAL NOT NULL DEFAULT 0,
+	value_month_percent DECIMAL NOT NULL DEFAULT 0,
+	receipt_fee DECIMAL NOT NULL DEFAULT 0,
+	issue_fee DECIMAL NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- End of day stock of every material, see updateInventorySnapshots
+CREATE TABLE inventory_snapshots (
+	snapshot_date DATE NOT NULL,
+	material_id int NOT NULL,
+	stock_id VARCHAR(100) NOT NULL,
+	location_id int,
+	customer_id int,
+	owner OWNER,
+	material_type MATERIAL_TYPE,
+	quantity int NOT NULL,
+	value DECIMAL NOT NULL,
+	PRIMARY KEY (snapshot_date, material_id)
+);
+
+CREATE INDEX transactions_log_updated_at ON transactions_log(updated_at);
+
+-- The snapshots from the day of an entry on are taken again when older entries
+-- are written, changed or deleted, see updateInventorySnapshots
+CREATE OR REPLACE FUNCTION clear_inventory_snapshots() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'INSERT' THEN
+		DELETE FROM inventory_snapshots WHERE snapshot_date >= OLD.updated_at::date;
+	END IF;
+	IF TG_OP <> 'DELETE' THEN
+		DELETE FROM inventory_snapshots WHERE snapshot_date >= NEW.updated_at::date;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_log_clear_snapshots
+	AFTER INSERT OR UPDATE OR DELETE ON transactions_log
+	FOR EACH ROW EXECUTE FUNCTION clear_inventory_snapshots();
+
+-- Balances of the materials before a moment: the nearest earlier snapshot
+-- rolled forward with the later transactions
+CREATE OR REPLACE FUNCTION material_balances(as_of timestamp)
+RETURNS TABLE (
+	material_id int,
+	stock_id VARCHAR,
+	location_id int,
+	customer_id int,
+	owner OWNER,
+	material_type MATERIAL_TYPE,
+	quantity bigint,
+	value DECIMAL
+) AS $$
+	WITH base AS (
+		SELECT MAX(s.snapshot_date) AS snapshot_date
+		FROM inventory_snapshots s
+		WHERE s.snapshot_date + 1 <= as_of
+	),
+	entries AS (
+		SELECT s.material_id, s.stock_id, s.quantity, s.value
+		FROM inventory_snapshots s
+		JOIN base ON s.snapshot_date = base.snapshot_date
+		UNION ALL
+		SELECT tl.material_id, tl.stock_id, tl.quantity_change, tl.quantity_change * tl.cost
+		FROM transactions_log tl, base
+		WHERE tl.updated_at < as_of AND
+			(base.snapshot_date IS NULL OR tl.updated_at >= base.snapshot_date + 1)
+	),
+	balances AS (
+		SELECT e.material_id, e.stock_id, SUM(e.quantity) AS quantity, SUM(e.value) AS value
+		FROM entries e
+		GROUP BY e.material_id, e.stock_id
+	)
+	SELECT b.material_id, b.stock_id,
+		COALESCE(m.location_id, s.location_id),
+		COALESCE(m.customer_id, s.customer_id),
+		COALESCE(m.owner, s.owner),
+		COALESCE(i.material_type, s.material_type),
+		b.quantity, b.value
+	FROM balances b
+	CROSS JOIN base
+	LEFT JOIN materials m ON m.material_id = b.material_id
+	LEFT JOIN items i ON i.item_id = m.item_id
+	LEFT JOIN inventory_snapshots s ON s.snapshot_date = base.snapshot_date AND s.material_id = b.material_id;
+$$ LANGUAGE sql STABLE;
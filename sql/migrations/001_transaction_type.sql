@@ -0,0 +1,33 @@
+-- Typed transactions_log entries for the statements.
+-- Existing entries are classified by their sign and job ticket:
+-- moves were not marked before, so the negative entries without a job ticket
+-- are treated as moves. A move wrote its destination entry right after the
+-- source entry, so a positive entry that follows a negative entry of the same
+-- stock ID with the opposite quantity and no job ticket is a move as well,
+-- the other positive entries are receipts.
+
+CREATE TYPE transaction_type AS ENUM('Receipt', 'Usage', 'Move', 'Adjustment');
+
+ALTER TABLE transactions_log
+	ADD COLUMN transaction_type TRANSACTION_TYPE NOT NULL DEFAULT 'Receipt';
+
+UPDATE transactions_log
+SET transaction_type = 'Usage'
+WHERE quantity_change < 0 AND COALESCE(job_ticket, '') <> '';
+
+UPDATE transactions_log
+SET transaction_type = 'Move'
+WHERE quantity_change < 0 AND COALESCE(job_ticket, '') = '';
+
+UPDATE transactions_log dst
+SET transaction_type = 'Move'
+FROM transactions_log src
+WHERE dst.quantity_change > 0 AND
+	src.transaction_id = dst.transaction_id - 1 AND
+	src.stock_id = dst.stock_id AND
+	src.quantity_change = -dst.quantity_change AND
+	COALESCE(src.job_ticket, '') = '';
+
+UPDATE transactions_log
+SET transaction_type = 'Adjustment'
+WHERE job_ticket = 'job_ticket';
@@ -0,0 +1,17 @@
+-- Saved report definitions with their filters, relative date range and schedule
+
+CREATE TABLE report_definitions (
+	definition_id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL UNIQUE,
+	report_type VARCHAR(100) NOT NULL,
+	stock_id VARCHAR(100),
+	customer_id int REFERENCES customers(customer_id),
+	location_id int REFERENCES locations(location_id),
+	material_type VARCHAR(100),
+	owner VARCHAR(100),
+	date_range VARCHAR(100),
+	output_format VARCHAR(10) NOT NULL,
+	schedule VARCHAR(100),
+	email TEXT,
+	last_run_at TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- Sending date of the incoming shipments for their age on the dashboard.
+-- The shipments sent before the migration are dated by the migration.
+
+ALTER TABLE incoming_materials ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW();
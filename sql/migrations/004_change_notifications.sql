@@ -0,0 +1,20 @@
+-- Change notifications for the live refresh of the open windows
+
+CREATE OR REPLACE FUNCTION notify_inventory_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('inventory_changes', TG_TABLE_NAME);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER materials_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON materials
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
+
+CREATE TRIGGER incoming_materials_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON incoming_materials
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
+
+CREATE TRIGGER transactions_log_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON transactions_log
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
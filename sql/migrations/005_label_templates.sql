@@ -0,0 +1,15 @@
+-- Label templates of the barcode and QR labels
+
+CREATE TABLE label_templates (
+	template_id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL UNIQUE,
+	width_mm DECIMAL NOT NULL,
+	height_mm DECIMAL NOT NULL,
+	barcode_type VARCHAR(20) NOT NULL,
+	font_size DECIMAL NOT NULL
+);
+
+INSERT INTO label_templates (name, width_mm, height_mm, barcode_type, font_size) VALUES
+	('4x2 in Code128', 101.6, 50.8, 'Code128', 14),
+	('4x2 in QR', 101.6, 50.8, 'QR', 14),
+	('2x1 in Code128', 50.8, 25.4, 'Code128', 8);
@@ -0,0 +1,16 @@
+-- Kits (bills of materials) of the customer products issued by the job tickets
+
+CREATE TABLE kits (
+	kit_id SERIAL PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	name VARCHAR(100) NOT NULL,
+	description TEXT,
+	CONSTRAINT kits_customer_name UNIQUE(customer_id, name)
+);
+
+CREATE TABLE kit_components (
+	kit_id int NOT NULL REFERENCES kits(kit_id) ON DELETE CASCADE,
+	stock_id VARCHAR(100) NOT NULL,
+	quantity_per_unit int NOT NULL CHECK (quantity_per_unit > 0),
+	PRIMARY KEY (kit_id, stock_id)
+);
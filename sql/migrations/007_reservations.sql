@@ -0,0 +1,17 @@
+-- Quantities of the customer materials reserved for the upcoming jobs
+
+CREATE TABLE reservations (
+	reservation_id SERIAL PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	stock_id VARCHAR(100) NOT NULL,
+	job_ticket VARCHAR(100) NOT NULL,
+	quantity int NOT NULL CHECK (quantity > 0),
+	consumed_quantity int NOT NULL DEFAULT 0,
+	required_date DATE,
+	notes TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER reservations_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON reservations
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_inventory_change();
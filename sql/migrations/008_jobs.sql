@@ -0,0 +1,40 @@
+-- Job tickets of the material usage, the tickets already used become open jobs
+
+CREATE TABLE jobs (
+	job_id SERIAL PRIMARY KEY,
+	job_ticket VARCHAR(100) NOT NULL UNIQUE,
+	customer_id int REFERENCES customers(customer_id),
+	description TEXT,
+	status VARCHAR(20) NOT NULL DEFAULT 'Open' CHECK (status IN ('Open', 'Closed')),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	due_date DATE,
+	closed_at TIMESTAMP
+);
+
+-- Closed jobs are locked against further postings
+CREATE OR REPLACE FUNCTION check_job_open() RETURNS trigger AS $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM jobs WHERE job_ticket = NEW.job_ticket AND status = 'Closed') THEN
+		RAISE EXCEPTION 'job % is closed', NEW.job_ticket;
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_log_check_job
+	BEFORE INSERT ON transactions_log
+	FOR EACH ROW WHEN (NEW.job_ticket IS NOT NULL AND NEW.job_ticket <> '')
+	EXECUTE FUNCTION check_job_open();
+
+INSERT INTO jobs (job_ticket, customer_id, created_at)
+SELECT tl.job_ticket, MIN(m.customer_id), COALESCE(MIN(tl.updated_at), NOW())
+FROM transactions_log tl
+LEFT JOIN materials m ON m.material_id = tl.material_id
+WHERE tl.job_ticket IS NOT NULL AND tl.job_ticket <> ''
+GROUP BY tl.job_ticket;
+
+INSERT INTO jobs (job_ticket, customer_id)
+SELECT DISTINCT ON (r.job_ticket) r.job_ticket, r.customer_id
+FROM reservations r
+WHERE NOT EXISTS (SELECT 1 FROM jobs j WHERE j.job_ticket = r.job_ticket)
+ORDER BY r.job_ticket, r.reservation_id;
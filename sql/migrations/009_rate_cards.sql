@@ -0,0 +1,11 @@
+-- Storage and handling rates of the customer owned materials
+
+CREATE TABLE rate_cards (
+	customer_id int PRIMARY KEY REFERENCES customers(customer_id),
+	location_day_rate DECIMAL NOT NULL DEFAULT 0,
+	unit_month_rate DECIMAL NOT NULL DEFAULT 0,
+	value_month_percent DECIMAL NOT NULL DEFAULT 0,
+	receipt_fee DECIMAL NOT NULL DEFAULT 0,
+	issue_fee DECIMAL NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
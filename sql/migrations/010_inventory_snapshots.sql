@@ -0,0 +1,79 @@
+-- End of day stock of every material for the historical reports
+
+CREATE TABLE inventory_snapshots (
+	snapshot_date DATE NOT NULL,
+	material_id int NOT NULL,
+	stock_id VARCHAR(100) NOT NULL,
+	location_id int,
+	customer_id int,
+	owner OWNER,
+	material_type MATERIAL_TYPE,
+	quantity int NOT NULL,
+	value DECIMAL NOT NULL,
+	PRIMARY KEY (snapshot_date, material_id)
+);
+
+CREATE INDEX transactions_log_updated_at ON transactions_log(updated_at);
+
+-- The snapshots from the day of an entry on are taken again when older entries
+-- are written, changed or deleted, see updateInventorySnapshots
+CREATE OR REPLACE FUNCTION clear_inventory_snapshots() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'INSERT' THEN
+		DELETE FROM inventory_snapshots WHERE snapshot_date >= OLD.updated_at::date;
+	END IF;
+	IF TG_OP <> 'DELETE' THEN
+		DELETE FROM inventory_snapshots WHERE snapshot_date >= NEW.updated_at::date;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_log_clear_snapshots
+	AFTER INSERT OR UPDATE OR DELETE ON transactions_log
+	FOR EACH ROW EXECUTE FUNCTION clear_inventory_snapshots();
+
+-- Balances of the materials before a moment: the nearest earlier snapshot
+-- rolled forward with the later transactions
+CREATE OR REPLACE FUNCTION material_balances(as_of timestamp)
+RETURNS TABLE (
+	material_id int,
+	stock_id VARCHAR,
+	location_id int,
+	customer_id int,
+	owner OWNER,
+	material_type MATERIAL_TYPE,
+	quantity bigint,
+	value DECIMAL
+) AS $$
+	WITH base AS (
+		SELECT MAX(s.snapshot_date) AS snapshot_date
+		FROM inventory_snapshots s
+		WHERE s.snapshot_date + 1 <= as_of
+	),
+	entries AS (
+		SELECT s.material_id, s.stock_id, s.quantity, s.value
+		FROM inventory_snapshots s
+		JOIN base ON s.snapshot_date = base.snapshot_date
+		UNION ALL
+		SELECT tl.material_id, tl.stock_id, tl.quantity_change, tl.quantity_change * tl.cost
+		FROM transactions_log tl, base
+		WHERE tl.updated_at < as_of AND
+			(base.snapshot_date IS NULL OR tl.updated_at >= base.snapshot_date + 1)
+	),
+	balances AS (
+		SELECT e.material_id, e.stock_id, SUM(e.quantity) AS quantity, SUM(e.value) AS value
+		FROM entries e
+		GROUP BY e.material_id, e.stock_id
+	)
+	SELECT b.material_id, b.stock_id,
+		COALESCE(m.location_id, s.location_id),
+		COALESCE(m.customer_id, s.customer_id),
+		COALESCE(m.owner, s.owner),
+		COALESCE(m.material_type, s.material_type),
+		b.quantity, b.value
+	FROM balances b
+	CROSS JOIN base
+	LEFT JOIN materials m ON m.material_id = b.material_id
+	LEFT JOIN inventory_snapshots s ON s.snapshot_date = base.snapshot_date AND s.material_id = b.material_id;
+$$ LANGUAGE sql STABLE;
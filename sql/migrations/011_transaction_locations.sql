@@ -0,0 +1,43 @@
+-- The log entries keep the location, the warehouse, the customer, the owner
+-- and the type of the material, the moves keep the source and the destination
+
+ALTER TABLE transactions_log
+	ADD COLUMN location_id int REFERENCES locations(location_id),
+	ADD COLUMN warehouse_id int REFERENCES warehouses(warehouse_id),
+	ADD COLUMN customer_id int REFERENCES customers(customer_id),
+	ADD COLUMN owner OWNER,
+	ADD COLUMN material_type MATERIAL_TYPE,
+	ADD COLUMN from_location_id int REFERENCES locations(location_id),
+	ADD COLUMN to_location_id int REFERENCES locations(location_id);
+
+-- The entries of the deleted materials stay empty
+UPDATE transactions_log tl
+SET location_id = m.location_id,
+	warehouse_id = l.warehouse_id,
+	customer_id = m.customer_id,
+	owner = m.owner,
+	material_type = m.material_type
+FROM materials m
+LEFT JOIN locations l ON l.location_id = m.location_id
+WHERE m.material_id = tl.material_id;
+
+-- Every destination entry of a move follows its source entry
+UPDATE transactions_log dst
+SET from_location_id = src.location_id,
+	to_location_id = dst.location_id
+FROM transactions_log src
+WHERE dst.transaction_type = 'Move' AND dst.quantity_change > 0 AND
+	src.transaction_id = dst.transaction_id - 1 AND
+	src.transaction_type = 'Move' AND
+	src.stock_id = dst.stock_id AND
+	src.quantity_change = -dst.quantity_change;
+
+UPDATE transactions_log src
+SET from_location_id = dst.from_location_id,
+	to_location_id = dst.to_location_id
+FROM transactions_log dst
+WHERE src.transaction_type = 'Move' AND src.quantity_change < 0 AND
+	dst.transaction_id = src.transaction_id + 1 AND
+	dst.transaction_type = 'Move' AND
+	dst.quantity_change = -src.quantity_change AND
+	dst.from_location_id IS NOT NULL;
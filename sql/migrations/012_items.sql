@@ -0,0 +1,94 @@
+-- Catalog of the customer stock IDs, the stock rows keep only the quantities
+-- of the items in the locations
+
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM materials WHERE customer_id IS NULL) THEN
+		RAISE EXCEPTION 'Set the customer of the materials without one before the upgrade';
+	END IF;
+END $$;
+
+CREATE TABLE items (
+	item_id serial PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	stock_id VARCHAR(100) NOT NULL,
+	material_type MATERIAL_TYPE NOT NULL,
+	description TEXT,
+	unit VARCHAR(20) NOT NULL DEFAULT 'each',
+	min_required_quantity int,
+	max_required_quantity int,
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	default_owner OWNER NOT NULL DEFAULT 'Customer',
+	CONSTRAINT items_customer_stock UNIQUE (customer_id, stock_id)
+);
+
+-- The item takes the details of the latest stock row of the stock ID
+INSERT INTO items (customer_id, stock_id, material_type, description,
+	min_required_quantity, max_required_quantity, is_active, default_owner)
+SELECT DISTINCT ON (customer_id, stock_id)
+	customer_id, stock_id, material_type, description,
+	min_required_quantity, max_required_quantity, is_active, owner
+FROM materials
+ORDER BY customer_id, stock_id, updated_at DESC NULLS LAST, material_id DESC;
+
+ALTER TABLE materials ADD COLUMN item_id int REFERENCES items(item_id);
+
+UPDATE materials m
+SET item_id = i.item_id
+FROM items i
+WHERE i.customer_id = m.customer_id AND i.stock_id = m.stock_id;
+
+ALTER TABLE materials ALTER COLUMN item_id SET NOT NULL;
+
+ALTER TABLE materials
+	DROP COLUMN material_type,
+	DROP COLUMN description,
+	DROP COLUMN min_required_quantity,
+	DROP COLUMN max_required_quantity,
+	DROP COLUMN is_active;
+
+-- Balances of the materials before a moment: the nearest earlier snapshot
+-- rolled forward with the later transactions
+CREATE OR REPLACE FUNCTION material_balances(as_of timestamp)
+RETURNS TABLE (
+	material_id int,
+	stock_id VARCHAR,
+	location_id int,
+	customer_id int,
+	owner OWNER,
+	material_type MATERIAL_TYPE,
+	quantity bigint,
+	value DECIMAL
+) AS $$
+	WITH base AS (
+		SELECT MAX(s.snapshot_date) AS snapshot_date
+		FROM inventory_snapshots s
+		WHERE s.snapshot_date + 1 <= as_of
+	),
+	entries AS (
+		SELECT s.material_id, s.stock_id, s.quantity, s.value
+		FROM inventory_snapshots s
+		JOIN base ON s.snapshot_date = base.snapshot_date
+		UNION ALL
+		SELECT tl.material_id, tl.stock_id, tl.quantity_change, tl.quantity_change * tl.cost
+		FROM transactions_log tl, base
+		WHERE tl.updated_at < as_of AND
+			(base.snapshot_date IS NULL OR tl.updated_at >= base.snapshot_date + 1)
+	),
+	balances AS (
+		SELECT e.material_id, e.stock_id, SUM(e.quantity) AS quantity, SUM(e.value) AS value
+		FROM entries e
+		GROUP BY e.material_id, e.stock_id
+	)
+	SELECT b.material_id, b.stock_id,
+		COALESCE(m.location_id, s.location_id),
+		COALESCE(m.customer_id, s.customer_id),
+		COALESCE(m.owner, s.owner),
+		COALESCE(i.material_type, s.material_type),
+		b.quantity, b.value
+	FROM balances b
+	CROSS JOIN base
+	LEFT JOIN materials m ON m.material_id = b.material_id
+	LEFT JOIN items i ON i.item_id = m.item_id
+	LEFT JOIN inventory_snapshots s ON s.snapshot_date = base.snapshot_date AND s.material_id = b.material_id;
+$$ LANGUAGE sql STABLE;
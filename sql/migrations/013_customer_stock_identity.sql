@@ -0,0 +1,59 @@
+-- A stock row is the quantity of an item of a customer in a location, the same
+-- stock ID of two customers in one location is kept in two rows
+
+-- The shared rows and the other customers. The accepted shipments are not
+-- kept, the receipts of a row cannot be told apart by customer: a row with
+-- receipts is shared with every other customer that has the stock ID in the
+-- catalog, the split rows start empty and take the quantities of the count.
+CREATE TABLE stock_collisions (
+	material_id int NOT NULL,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	stock_id VARCHAR(100) NOT NULL,
+	location_id int REFERENCES locations(location_id),
+	owner OWNER NOT NULL,
+	quantity int NOT NULL,
+	new_material_id int,
+	detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (material_id, customer_id)
+);
+
+INSERT INTO stock_collisions (material_id, customer_id, stock_id, location_id, owner, quantity)
+SELECT m.material_id, i.customer_id, m.stock_id, m.location_id, m.owner, m.quantity
+FROM materials m
+JOIN items i ON i.stock_id = m.stock_id AND i.customer_id <> m.customer_id
+WHERE EXISTS (
+	SELECT 1 FROM transactions_log tl
+	WHERE tl.material_id = m.material_id AND tl.transaction_type = 'Receipt'
+);
+
+ALTER TABLE items ADD CONSTRAINT items_identity UNIQUE (item_id, customer_id, stock_id);
+
+ALTER TABLE materials DROP CONSTRAINT pk_location_stock_owner;
+ALTER TABLE materials ADD PRIMARY KEY (material_id);
+ALTER TABLE materials ALTER COLUMN customer_id SET NOT NULL;
+ALTER TABLE materials ADD CONSTRAINT materials_item_location_owner UNIQUE (item_id, location_id, owner);
+ALTER TABLE materials ADD CONSTRAINT materials_item_identity FOREIGN KEY (item_id, customer_id, stock_id)
+	REFERENCES items (item_id, customer_id, stock_id);
+
+-- The split rows of the other customers
+INSERT INTO materials (item_id, stock_id, location_id, customer_id, notes, quantity, cost, updated_at, owner)
+SELECT i.item_id, sc.stock_id, sc.location_id, sc.customer_id,
+	'Split from material ' || sc.material_id, 0, m.cost, NOW(), sc.owner
+FROM stock_collisions sc
+JOIN materials m ON m.material_id = sc.material_id
+JOIN items i ON i.customer_id = sc.customer_id AND i.stock_id = sc.stock_id
+ON CONFLICT (item_id, location_id, owner) DO NOTHING;
+
+UPDATE stock_collisions sc
+SET new_material_id = m.material_id
+FROM items i
+JOIN materials m ON m.item_id = i.item_id
+WHERE i.customer_id = sc.customer_id AND i.stock_id = sc.stock_id AND
+	m.location_id IS NOT DISTINCT FROM sc.location_id AND m.owner = sc.owner;
+
+-- The rows to count: the quantity of the shared row is split by the count
+SELECT sc.material_id, sc.new_material_id, c.name AS customer, sc.stock_id, sc.location_id,
+	sc.owner, sc.quantity
+FROM stock_collisions sc
+JOIN customers c ON c.customer_id = sc.customer_id
+ORDER BY sc.stock_id, sc.location_id;
@@ -0,0 +1,7 @@
+-- Packaging units of an item, the factor is the quantity of the base unit in one unit
+CREATE TABLE item_units (
+	item_id int NOT NULL REFERENCES items(item_id) ON DELETE CASCADE,
+	unit VARCHAR(20) NOT NULL,
+	factor int NOT NULL CHECK (factor > 1),
+	PRIMARY KEY (item_id, unit)
+);
@@ -0,0 +1,21 @@
+-- Ownership entries move stock between the Customer and the Tag rows
+ALTER TYPE transaction_type ADD VALUE 'Ownership';
+
+-- Sales of stock between the customer and Tag at an agreed price per unit,
+-- the book value is the FIFO cost of the cost layers of the previous owner
+CREATE TABLE ownership_transfers (
+	transfer_id serial PRIMARY KEY,
+	customer_id int NOT NULL REFERENCES customers(customer_id),
+	item_id int NOT NULL REFERENCES items(item_id),
+	stock_id VARCHAR(100) NOT NULL,
+	location_id int REFERENCES locations(location_id),
+	from_material_id int NOT NULL REFERENCES materials(material_id),
+	to_material_id int NOT NULL REFERENCES materials(material_id),
+	from_owner OWNER NOT NULL,
+	to_owner OWNER NOT NULL CHECK (to_owner <> from_owner),
+	quantity int NOT NULL CHECK (quantity > 0),
+	transfer_price DECIMAL NOT NULL CHECK (transfer_price >= 0),
+	book_value DECIMAL NOT NULL,
+	notes TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);